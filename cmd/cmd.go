@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	goerrors "errors"
 	"log"
 	"os"
 
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -17,9 +19,15 @@ func init() {
 }
 
 // Main will take the workload of executing/starting the cli, when the command is passed to it.
+// It exits with the code mapped to the error returned by the command, see errors.ExitCode.
 func Main() {
 	if err := execute(os.Args[1:]); err != nil {
-		log.Fatal(err)
+		var driftsFound *errors.DriftsFoundError
+		if !goerrors.As(err, &driftsFound) {
+			log.Println(err)
+		}
+
+		os.Exit(errors.ExitCode(err))
 	}
 }
 
@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
+	"github.com/nikhilsbhat/helm-drift/pkg"
 	"github.com/nikhilsbhat/helm-drift/pkg/errors"
 	"github.com/nikhilsbhat/helm-drift/version"
 	"github.com/spf13/cobra"
@@ -56,30 +59,42 @@ helm drift run prometheus-standalone --from-release`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			drifts.SetLogger(drifts.LogLevel)
 			drifts.SetWriter(os.Stdout)
-			drifts.SetOutputFormats()
+			if err := drifts.SetOutputFormats(); err != nil {
+				return err
+			}
+
+			if err := drifts.ValidateFailOn(); err != nil {
+				return err
+			}
+
 			drifts.SetRenderer()
 
 			cmd.SilenceUsage = true
 
-			drifts.SetKubeConfig(envSettings.KubeConfig)
-			drifts.SetKubeContext(envSettings.KubeContext)
-			drifts.SetNamespace(envSettings.Namespace)
+			if err := setupDrift(true); err != nil {
+				return err
+			}
 
-			if !drifts.SkipValidation {
-				if !drifts.ValidatePrerequisite() {
-					return &errors.PreValidationError{Message: "validation failed, please address the prerequisite errors to identify drifts"}
-				}
+			if err := drifts.SetBaseline(); err != nil {
+				return err
 			}
 
-			drifts.GetDrift()
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
 
-			return nil
+			report, err := drifts.GetDrift(ctx)
+			if err != nil {
+				return err
+			}
+
+			return drifts.ExitError(report)
 		},
 	}
 
 	driftRunCommand.SilenceErrors = true
 	registerCommonFlags(driftRunCommand)
 	registerDriftFlags(driftRunCommand)
+	registerBaselineFlags(driftRunCommand)
 
 	return driftRunCommand
 }
@@ -96,42 +111,318 @@ helm drift all --kube-context k3d-sample -n sample`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			cmd.SilenceUsage = true
 
+			if err := loadConfig(cmd); err != nil {
+				return err
+			}
+
 			drifts.SetLogger(drifts.LogLevel)
 			drifts.SetWriter(os.Stdout)
-			drifts.SetOutputFormats()
-			drifts.SetRenderer()
+			if err := drifts.SetOutputFormats(); err != nil {
+				return err
+			}
 
-			if err := drifts.SetReleasesToSkips(); err != nil {
+			if err := drifts.ValidateFailOn(); err != nil {
 				return err
 			}
 
-			drifts.SetKubeConfig(envSettings.KubeConfig)
-			drifts.SetKubeContext(envSettings.KubeContext)
-			drifts.SetNamespace(envSettings.Namespace)
+			drifts.SetRenderer()
 
-			if !drifts.SkipValidation {
-				if !drifts.ValidatePrerequisite() {
-					return &errors.PreValidationError{Message: "validation failed, please address the prerequisite errors to identify drifts"}
-				}
+			if err := setupDrift(true); err != nil {
+				return err
+			}
+
+			if err := drifts.SetBaseline(); err != nil {
+				return err
 			}
 
 			drifts.All = true
 
-			drifts.GetAllDrift()
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
 
-			return nil
+			report, err := drifts.GetAllDrift(ctx)
+			if err != nil {
+				return err
+			}
+
+			return drifts.ExitError(report)
 		},
 	}
 
 	driftAllCommand.SilenceErrors = true
 	registerCommonFlags(driftAllCommand)
 	registerDriftAllFlags(driftAllCommand)
-	driftAllCommand.PersistentFlags().StringArrayVar(&drifts.SkipReleases, "skip-release", nil,
-		"list of helm releases to be skipped for identifying helm drifts, ex: ReleaseName=Namespace | ReleaseName=Namespace")
+	registerDriftAllTableFlags(driftAllCommand)
+	registerBaselineFlags(driftAllCommand)
 
 	return driftAllCommand
 }
 
+func getServeCommand() *cobra.Command {
+	driftServeCommand := &cobra.Command{
+		Use:   "serve",
+		Short: "Identifies drifts from all releases periodically and exposes them as prometheus metrics.",
+		Long: `It runs as a long-running service, identifying drifts from all releases present in the cluster on every interval.
+The results of the latest scan are exposed as prometheus metrics, releases whose drifts could not be identified are counted as scan errors.`,
+		Example: `helm drift serve --kube-context k3d-sample --interval 10m
+helm drift serve --kube-context k3d-sample --listen-address :8080 --diff-engine native`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+
+			if err := loadConfig(cmd); err != nil {
+				return err
+			}
+
+			drifts.SetLogger(drifts.LogLevel)
+			drifts.SetWriter(os.Stdout)
+
+			if err := setupDrift(true); err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return drifts.Serve(ctx, listenAddress, metricsPath, scanInterval)
+		},
+	}
+
+	driftServeCommand.SilenceErrors = true
+	registerDetectFlags(driftServeCommand)
+	registerDriftAllFlags(driftServeCommand)
+	registerServeFlags(driftServeCommand)
+
+	return driftServeCommand
+}
+
+func getFixCommand() *cobra.Command {
+	driftFixCommand := &cobra.Command{
+		Use:   "fix [RELEASE] [CHART] [flags]",
+		Short: "Restores the drifted resources of a selected chart or release.",
+		Long: `It identifies the drifts of the specified chart or release and re-applies only the manifests of the drifted resources,
+with server-side apply, without upgrading the release. Resources missing from the cluster are re-created.`,
+		Example: `helm drift fix prometheus-standalone --from-release --dry-run
+helm drift fix prometheus-standalone --from-release --interactive --allow-kind ConfigMap,Secret
+helm drift fix prometheus-standalone path/to/chart/prometheus-standalone -f ~/path/to/override-config.yaml`,
+		Args: validateAndSetArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			drifts.SetLogger(drifts.LogLevel)
+			drifts.SetWriter(os.Stdout)
+			drifts.SetReader(os.Stdin)
+			if err := drifts.SetOutputFormats(); err != nil {
+				return err
+			}
+
+			drifts.SetRenderer()
+
+			cmd.SilenceUsage = true
+
+			if err := setupDrift(true); err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			report, err := drifts.Fix(ctx)
+			if err != nil {
+				return err
+			}
+
+			if err = drifts.RenderFix(report); err != nil {
+				return err
+			}
+
+			if failed := report.Count(pkg.RemediationFailed); failed != 0 {
+				return &errors.DriftError{Message: fmt.Sprintf("restoring %d of the drifted resources failed", failed)}
+			}
+
+			return nil
+		},
+	}
+
+	driftFixCommand.SilenceErrors = true
+	registerCommonFlags(driftFixCommand)
+	registerDriftFlags(driftFixCommand)
+	registerFixFlags(driftFixCommand)
+
+	return driftFixCommand
+}
+
+func getCompareCommand() *cobra.Command {
+	driftCompareCommand := &cobra.Command{
+		Use:   "compare [RELEASE] [flags]",
+		Short: "Compares the manifests of a release across kube contexts or namespaces.",
+		Long: `It diffs the manifests of the specified release against the manifests of the same (or another) release
+from another kube context or namespace, reporting the resources that differ between them and the ones present in only one of them.`,
+		Example: `helm drift compare prometheus-standalone --kube-context staging --against-kube-context production
+helm drift compare prometheus-standalone -n sample --against-namespace sample-canary -o json`,
+		Args: cobra.ExactArgs(getArgumentCountRelease),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			if err := loadConfig(cmd); err != nil {
+				return err
+			}
+
+			drifts.SetLogger(drifts.LogLevel)
+			drifts.SetWriter(os.Stdout)
+			if err := drifts.SetOutputFormats(); err != nil {
+				return err
+			}
+
+			if err := drifts.ValidateFailOn(); err != nil {
+				return err
+			}
+
+			drifts.SetRenderer()
+
+			if err := setupDrift(false); err != nil {
+				return err
+			}
+
+			drifts.SetRelease(args[0])
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			report, err := drifts.Compare(ctx, compareWith)
+			if err != nil {
+				return err
+			}
+
+			if err = drifts.Render(report); err != nil {
+				return err
+			}
+
+			return drifts.ExitError(report)
+		},
+	}
+
+	driftCompareCommand.SilenceErrors = true
+	registerCommonFlags(driftCompareCommand)
+	registerCompareFlags(driftCompareCommand)
+
+	return driftCompareCommand
+}
+
+func getBaselineCommand() *cobra.Command {
+	baselineCommand := &cobra.Command{
+		Use:   "baseline [command]",
+		Short: "Manages the baseline of drifts that are known and accepted.",
+		Long: `Drifts saved to the baseline are accepted, when passed with '--baseline' to the commands run and all
+only the drifts that are new or have changed since the baseline was saved are reported as drifts.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	baselineCommand.AddCommand(getBaselineSaveCommand())
+
+	return baselineCommand
+}
+
+func getBaselineSaveCommand() *cobra.Command {
+	baselineSaveCommand := &cobra.Command{
+		Use:   "save [RELEASE] [CHART] [flags]",
+		Short: "Saves the drifts identified from a selected chart/release, or from all releases, as baseline.",
+		Long:  "It identifies the drifts from the specified chart/release, or from all the releases with '--all', and saves them to the baseline file.",
+		Example: `helm drift baseline save prometheus-standalone --from-release --file prometheus-baseline.yaml
+helm drift baseline save --all --kube-context k3d-sample
+helm drift run prometheus-standalone --from-release --baseline prometheus-baseline.yaml`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			// loading the config first, as '--all' could be set from it.
+			if err := loadConfig(cmd); err != nil {
+				return err
+			}
+
+			if !drifts.All {
+				return setArgs(cmd, args)
+			}
+
+			return cobra.NoArgs(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			drifts.SetLogger(drifts.LogLevel)
+
+			if err := setupDrift(true); err != nil {
+				return err
+			}
+
+			// drifts are saved as they are, without accepting the ones from an existing baseline.
+			drifts.Baseline = ""
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			target := pkg.Target{All: drifts.All}
+			if !drifts.All {
+				target.Release = args[0]
+			}
+
+			if !drifts.All && !drifts.FromRelease {
+				target.Chart = args[1]
+			}
+
+			report, err := drifts.Detect(ctx, target)
+			if err != nil {
+				return err
+			}
+
+			return drifts.SaveBaseline(report, baselineFile)
+		},
+	}
+
+	baselineSaveCommand.SilenceErrors = true
+	registerCommonFlags(baselineSaveCommand)
+	registerDriftFlags(baselineSaveCommand)
+	registerDriftAllFlags(baselineSaveCommand)
+	registerBaselineSaveFlags(baselineSaveCommand)
+
+	return baselineSaveCommand
+}
+
+// setupDrift sets up drifts with the releases to skip, ignore rules, normalizers, defaults and mutators from the flags,
+// and with the kube settings. Every command identifying drifts sets up the same way, so that the drifts saved to the baseline
+// match the ones identified by the commands run and all. The prerequisites are validated when asked to, unless skipped.
+func setupDrift(validate bool) error {
+	if err := drifts.SetReleasesToSkips(); err != nil {
+		return err
+	}
+
+	if err := drifts.SetIgnoreRules(); err != nil {
+		return err
+	}
+
+	if err := drifts.SetNormalizers(); err != nil {
+		return err
+	}
+
+	if err := drifts.SetDefaultsSchema(); err != nil {
+		return err
+	}
+
+	if err := drifts.SetMutators(); err != nil {
+		return err
+	}
+
+	drifts.SetKubeConfig(envSettings.KubeConfig)
+	drifts.SetKubeContext(envSettings.KubeContext)
+	drifts.SetNamespace(envSettings.Namespace)
+
+	if validate && !drifts.SkipValidation {
+		if !drifts.ValidatePrerequisite() {
+			return &errors.PreValidationError{Message: "validation failed, please address the prerequisite errors to identify drifts"}
+		}
+	}
+
+	return nil
+}
+
 func versionConfig(_ *cobra.Command, _ []string) error {
 	buildInfo, err := json.Marshal(version.GetBuildInfo())
 	if err != nil {
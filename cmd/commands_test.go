@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/internal/testutil"
+	"github.com/nikhilsbhat/helm-drift/pkg"
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+)
+
+const sampleChartManifest = `---
+# Source: sample/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: sample
+  namespace: sample
+  annotations:
+    checksum/config: rendered
+spec:
+  replicas: 1
+`
+
+// newFakeClusterOptions returns the options to have drifts talk to a fake cluster holding the deployment 'sample'.
+func newFakeClusterOptions() []pkg.Option {
+	live := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{"name": "sample", "namespace": "sample"},
+		"spec":       map[string]any{"replicas": int64(2)},
+	}}
+
+	return []pkg.Option{
+		pkg.WithKubeClient(kubeFake.NewClientset()),
+		pkg.WithDynamicClient(testutil.NewFakeDynamicClient(live)),
+		pkg.WithRESTMapper(testutil.NewFakeRESTMapper()),
+	}
+}
+
+func TestServeCommandFlags(t *testing.T) {
+	t.Cleanup(func() {
+		drifts = pkg.Drift{}
+	})
+
+	command := getServeCommand()
+
+	for _, flag := range []string{"output", "disable-error-on-drift", "fail-on"} {
+		assert.Nil(t, command.PersistentFlags().Lookup(flag), "serve should not register '--%s' as it reports no drifts to exit on", flag)
+	}
+
+	for _, flag := range []string{"diff-engine", "ignore-file", "detect-orphans", "interval"} {
+		assert.NotNil(t, command.PersistentFlags().Lookup(flag), "serve should register '--%s'", flag)
+	}
+}
+
+func TestBaselineRoundTrip(t *testing.T) {
+	t.Cleanup(func() {
+		drifts = pkg.Drift{}
+	})
+
+	t.Chdir(t.TempDir())
+
+	// the drifts identified are rendered on to stdout by the command run.
+	stdout, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	require.NoError(t, err)
+
+	os.Stdout, stdout = stdout, os.Stdout
+	t.Cleanup(func() {
+		os.Stdout = stdout
+	})
+
+	envSettings = &EnvSettings{Namespace: "sample"}
+
+	ignoreFile := filepath.Join(t.TempDir(), "ignore.yaml")
+	ignoreRules, err := yaml.Marshal(pkg.IgnoreRules{Rules: []*pkg.IgnoreRule{
+		{Kinds: []string{"Deployment"}, Paths: []string{"metadata.annotations"}},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(ignoreFile, ignoreRules, 0o600))
+
+	baseline := filepath.Join(t.TempDir(), pkg.DefaultBaselineFile)
+	commonArgs := []string{"sample", "path/to/chart/sample", "--skip-validation", "--temp-path", t.TempDir(), "--ignore-file", ignoreFile}
+
+	// the chart is rendered as the manifest set, which is reported as diffed, so the drifts reported change with the fields ignored.
+	exec := &testutil.FakeExec{Output: sampleChartManifest, DiffManifests: true}
+
+	execute := func(command *cobra.Command, args ...string) error {
+		command.SetArgs(append(append([]string{}, commonArgs...), args...))
+		drifts.LogLevel = "error"
+
+		for _, option := range append(newFakeClusterOptions(), pkg.WithCommandExecutor(exec.Executor())) {
+			option(&drifts)
+		}
+
+		return command.ExecuteContext(t.Context())
+	}
+
+	require.NoError(t, execute(getBaselineSaveCommand(), "--file", baseline))
+
+	content, err := os.ReadFile(baseline)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "checksum/config", "fields ignored should be left out of the drifts saved as baseline")
+
+	t.Run("should accept the drifts saved with the same ignore rules", func(t *testing.T) {
+		assert.NoError(t, execute(getRunCommand(), "--baseline", baseline))
+	})
+
+	t.Run("should report the drifts that have changed since the baseline", func(t *testing.T) {
+		exec.Output = strings.ReplaceAll(sampleChartManifest, "replicas: 1", "replicas: 3")
+
+		var driftsFound *errors.DriftsFoundError
+		assert.ErrorAs(t, execute(getRunCommand(), "--baseline", baseline), &driftsFound)
+	})
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"helm.sh/helm/v3/pkg/helmpath"
+	"sigs.k8s.io/yaml"
+)
+
+// driftConfigFile is the configuration file looked up in the working directory and in $HELM_CONFIG_HOME,
+// when no configuration file is passed with '--config'.
+const driftConfigFile = ".helm-drift.yaml"
+
+var configFile string
+
+// loadConfig loads the configuration file on to drifts, values of the flags that are set explicitly
+// takes precedence over the ones from the configuration file.
+func loadConfig(cmd *cobra.Command) error {
+	config, err := findConfigFile(configFile)
+	if err != nil {
+		return err
+	}
+
+	if len(config) == 0 {
+		return nil
+	}
+
+	content, err := os.ReadFile(config)
+	if err != nil {
+		return &errors.DriftError{Message: fmt.Sprintf("reading config file '%s' errored with '%v'", config, err)}
+	}
+
+	flagValues := make(map[*pflag.Flag]any)
+
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+			// copying since decoding the config file would reuse the underlying array of the slice.
+			flagValues[flag] = append([]string{}, sliceValue.GetSlice()...)
+
+			return
+		}
+
+		flagValues[flag] = flag.Value.String()
+	})
+
+	if err = yaml.UnmarshalStrict(content, &drifts); err != nil {
+		return &errors.DriftError{Message: fmt.Sprintf("parsing config file '%s' errored with '%v'", config, err)}
+	}
+
+	for flag, value := range flagValues {
+		switch flagValue := value.(type) {
+		case []string:
+			err = flag.Value.(pflag.SliceValue).Replace(flagValue)
+		case string:
+			err = flag.Value.Set(flagValue)
+		}
+
+		if err != nil {
+			return &errors.DriftError{Message: fmt.Sprintf("setting flag '%s' over config file errored with '%v'", flag.Name, err)}
+		}
+	}
+
+	return nil
+}
+
+// findConfigFile returns the configuration file to be loaded, it would be empty if none of the configuration files exist.
+func findConfigFile(config string) (string, error) {
+	if len(config) != 0 {
+		if _, err := os.Stat(config); err != nil {
+			return "", &errors.DriftError{Message: fmt.Sprintf("config file '%s' not found: %v", config, err)}
+		}
+
+		return config, nil
+	}
+
+	for _, path := range []string{driftConfigFile, helmpath.ConfigPath(driftConfigFile)} {
+		if _, err := os.Stat(path); err == nil {
+			return filepath.Clean(path), nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Cleanup(func() {
+		drifts = pkg.Drift{}
+		configFile = ""
+	})
+
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(config, []byte(`
+kind: [StatefulSet]
+skip_kinds: [Secret]
+value_files: [values.yaml]
+ignore_hpa_changes: true
+log_level: debug
+limit: 5
+`), 0o600))
+
+	drifts = pkg.Drift{}
+	command := getAllCommand()
+	registerFlags(command)
+
+	require.NoError(t, command.ParseFlags([]string{"--config", config, "--kind", "Deployment", "--limit-threads", "2", "-f", "override.yaml"}))
+	require.NoError(t, loadConfig(command))
+
+	assert.Equal(t, []string{"Deployment"}, drifts.Kind)
+	assert.Equal(t, 2, drifts.Limit)
+	assert.Equal(t, pkg.ValueFiles{"override.yaml"}, drifts.ValueFiles)
+	assert.Equal(t, []string{"Secret"}, drifts.SkipKinds)
+	assert.True(t, drifts.IgnoreHPAChanges)
+	assert.Equal(t, "debug", drifts.LogLevel)
+	assert.Equal(t, []string{"hook-succeeded", "hook-failed"}, drifts.IgnoreHookTypes)
+}
+
+func TestLoadConfigWithUnknownFields(t *testing.T) {
+	t.Cleanup(func() {
+		drifts = pkg.Drift{}
+		configFile = ""
+	})
+
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(config, []byte("unknown: true\n"), 0o600))
+
+	drifts = pkg.Drift{}
+	command := getAllCommand()
+
+	require.NoError(t, command.ParseFlags([]string{"--config", config}))
+	assert.Error(t, loadConfig(command))
+}
+
+func TestFindConfigFile(t *testing.T) {
+	workingDir := t.TempDir()
+	t.Chdir(workingDir)
+	t.Setenv("HELM_CONFIG_HOME", t.TempDir())
+
+	found, err := findConfigFile("")
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	_, err = findConfigFile(filepath.Join(workingDir, "missing.yaml"))
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(os.Getenv("HELM_CONFIG_HOME"), driftConfigFile), []byte("{}"), 0o600))
+
+	found, err = findConfigFile("")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(os.Getenv("HELM_CONFIG_HOME"), driftConfigFile), found)
+
+	require.NoError(t, os.WriteFile(driftConfigFile, []byte("{}"), 0o600))
+
+	found, err = findConfigFile("")
+	require.NoError(t, err)
+	assert.Equal(t, driftConfigFile, found)
+}
+
+func TestBaselineSaveAllFromConfig(t *testing.T) {
+	t.Cleanup(func() {
+		drifts = pkg.Drift{}
+		configFile = ""
+	})
+
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(config, []byte("all: true\n"), 0o600))
+
+	drifts = pkg.Drift{}
+	command := getBaselineSaveCommand()
+	registerFlags(command)
+
+	require.NoError(t, command.ParseFlags([]string{"--config", config}))
+	require.NoError(t, command.Args(command, nil), "'all' from the config should not expect [RELEASE] [CHART]")
+	assert.True(t, drifts.All)
+
+	drifts = pkg.Drift{}
+	assert.Error(t, command.Args(command, []string{"sample"}), "'all' from the config should not accept [RELEASE]")
+}
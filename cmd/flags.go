@@ -38,8 +38,65 @@ func registerFlags(cmd *cobra.Command) {
 		"the value to be set for flag --concurrency of 'kubectl diff'")
 }
 
-// Registers flags to support command run/all.
+// Registers flags to support command fix.
+func registerFixFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVarP(&drifts.DryRun, "dry-run", "", false,
+		"when enabled, the manifests of the drifted resources are applied in dry-run mode, reporting what would be restored without changing them")
+	cmd.PersistentFlags().BoolVarP(&drifts.Interactive, "interactive", "i", false,
+		"when enabled, the drifts of every resource are shown and it is restored only when confirmed")
+	cmd.PersistentFlags().StringSliceVarP(&drifts.FixKinds, "allow-kind", "", nil,
+		"kubernetes resource kinds allowed to be restored, drifted resources of other kinds are skipped (ex: --allow-kind ConfigMap,Secret). "+
+			"If not set, resources of all kinds are restored")
+}
+
+// Registers flags to match the drifts against the baseline, for commands run/all.
+func registerBaselineFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&drifts.Baseline, "baseline", "", "",
+		"path to the baseline saved with 'helm drift baseline save', drifts matching it (by the resource and its field level changes) are accepted "+
+			"and only the drifts that are new or have changed since are reported as drifts")
+}
+
+// Registers flags specific to command, baseline save.
+func registerBaselineSaveFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&baselineFile, "file", "", pkg.DefaultBaselineFile,
+		"path to the file to which the baseline is saved")
+	cmd.PersistentFlags().BoolVarP(&drifts.All, "all", "", false,
+		"enable the flag to save the drifts from all the releases of the cluster (or of the namespace when set) as baseline")
+}
+
+// Registers flags to support command compare.
+func registerCompareFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&compareWith.KubeContext, "against-kube-context", "", "",
+		"kube context of the cluster with the release to compare against (ex: production), defaults to the kube context set")
+	cmd.PersistentFlags().StringVarP(&compareWith.Namespace, "against-namespace", "", "",
+		"namespace of the release to compare against, defaults to the namespace set")
+	cmd.PersistentFlags().StringVarP(&compareWith.Release, "against-release", "", "",
+		"name of the release to compare against, defaults to the name of the release being compared")
+}
+
+// Registers flags to identify the drifts, along with the flags to render them and exit on them,
+// for the commands run, all, fix, compare and baseline save.
 func registerCommonFlags(cmd *cobra.Command) {
+	registerDetectFlags(cmd)
+	registerOutputFlags(cmd)
+}
+
+// Registers flags to render the drifts identified and to exit with error on them, for the commands that report the drifts once.
+func registerOutputFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&drifts.OutputFormat, "output", "o", "",
+		"the format to which the output should be rendered to, it should be one of yaml|json|csv|table|junit|sarif|html|markdown, if nothing specified it sets to default")
+	cmd.PersistentFlags().BoolVarP(&drifts.DisableExitWithError, "disable-error-on-drift", "d", false,
+		"enabling this would disable exiting with error if drifts were identified, resources that timed out still exit with error")
+	cmd.PersistentFlags().StringVarP(&drifts.FailOn, "fail-on", "", pkg.FailOnAny,
+		"drifts to exit with error on, it should be one of any|missing|none. With 'missing' helm drift exits with error "+
+			"only when resources are missing from the cluster, the rest of the drifts are reported without failing")
+}
+
+// Registers flags to identify the drifts, for all the commands identifying them including serve.
+func registerDetectFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&configFile, "config", "", "",
+		"path to the config file with values for the flags of helm drift, flags set explicitly take precedence over the values from the file. "+
+			"If not set, '"+driftConfigFile+"' would be looked up in the current directory and then in $HELM_CONFIG_HOME")
 	cmd.PersistentFlags().StringVarP(&drifts.Regex, "regex", "", pkg.TemplateRegex,
 		"regex used to split helm template rendered")
 	cmd.PersistentFlags().StringVarP(&drifts.TempPath, "temp-path", "", filepath.Join(homedir.HomeDir(), ".helm-drift", "templates"),
@@ -48,10 +105,9 @@ func registerCommonFlags(cmd *cobra.Command) {
 		"enable the flag if prerequisite validation needs to be skipped")
 	cmd.PersistentFlags().BoolVarP(&drifts.SkipClean, "skip-cleaning", "", false,
 		"enable the flag to skip cleaning the manifests rendered on to disk")
-	cmd.PersistentFlags().StringVarP(&drifts.OutputFormat, "output", "o", "",
-		"the format to which the output should be rendered to, it should be one of yaml|json|table, if nothing specified it sets to default")
-	cmd.PersistentFlags().BoolVarP(&drifts.DisableExitWithError, "disable-error-on-drift", "d", false,
-		"enabling this would disable exiting with error if drifts were identified")
+	cmd.PersistentFlags().StringVarP(&drifts.DiffEngine, "diff-engine", "", pkg.DiffEngineKubectl,
+		"engine used to identify drifts, it should be one of kubectl|native. The 'native' engine computes the diffs in-process "+
+			"using server-side apply dry-run and does not require kubectl")
 	cmd.PersistentFlags().StringVarP(&drifts.CustomDiff, "custom-diff", "", "",
 		"custom diff command to use instead of default, the command passed here would be set under `KUBECTL_EXTERNAL_DIFF`."+
 			"More information can be found here https://kubernetes.io/docs/reference/generated/kubectl/kubectl-commands#diff")
@@ -66,7 +122,40 @@ func registerCommonFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringSliceVarP(&drifts.IgnoreHookTypes, "ignore-hooks", "", []string{"hook-succeeded", "hook-failed"},
 		"list of hooks to ignore while identifying the drifts")
 	cmd.PersistentFlags().BoolVarP(&drifts.IgnoreHPAChanges, "ignore-hpa-changes", "", false,
-		"when enabled, the drifts caused on workload due to hpa scaling would be ignored")
+		"when enabled, the drifts caused on workload due to hpa scaling would be ignored, same as '--ignore-mutators hpa'")
+	cmd.PersistentFlags().BoolVarP(&drifts.IgnoreVPAChanges, "ignore-vpa-changes", "", false,
+		"when enabled, the drifts on the resource requests and limits of the workloads updated by vpa would be ignored, "+
+			"same as '--ignore-mutators vpa'")
+	cmd.PersistentFlags().StringSliceVarP(&drifts.IgnoreMutators, "ignore-mutators", "", nil,
+		"controllers mutating the resources once applied, whose changes on the fields they own are ignored. "+
+			"It should be any of hpa|keda|argo-rollouts|vpa|cert-manager|istio (ex: --ignore-mutators hpa,istio)")
+	cmd.PersistentFlags().BoolVarP(&drifts.IgnoreDefaults, "ignore-defaults", "", false,
+		"when enabled, changes on the fields left out from the manifests whose live value is the default set by the API server are ignored, "+
+			"the defaults are resolved from the OpenAPI schema selected with '--defaults-schema'")
+	cmd.PersistentFlags().StringVarP(&drifts.DefaultsSchema, "defaults-schema", "", pkg.DefaultsSchemaCluster,
+		"schema the defaults are resolved from with '--ignore-defaults', it should be one of cluster|bundled. With 'cluster' the OpenAPI schema "+
+			"is fetched from the cluster, falling back to the schema bundled with helm drift for the fields it declares no default for")
+	cmd.PersistentFlags().StringVarP(&drifts.IgnoreFile, "ignore-file", "", "",
+		"path to the file with rules to ignore drifts on specific fields of the resources, "+
+			"if not set rules would be loaded from '"+pkg.DefaultIgnoreFile+"' when present in the current directory")
+	cmd.PersistentFlags().StringSliceVarP(&drifts.Normalize, "normalize", "", nil,
+		"steps to normalize the manifests and the live objects with before identifying drifts, so that cosmetic differences are not reported as drifts. "+
+			"Steps run in the order set and should be any of clean|helm-labels|empty|drop=<path> "+
+			"(ex: --normalize clean,helm-labels,drop=spec.revisionHistoryLimit)")
+	cmd.PersistentFlags().VarP(&drifts.Timeout, "timeout", "",
+		"time to wait for the drifts to be identified, the kubectl/helm commands and the kubernetes API calls in flight are cancelled once elapsed "+
+			"and the resources yet to be diffed are reported as timed-out (ex: 5m), 0s disables it")
+	cmd.PersistentFlags().VarP(&drifts.ResourceTimeout, "resource-timeout", "",
+		"time to wait for the drifts of a single resource to be identified, resources exceeding it are reported as timed-out "+
+			"instead of failing the whole run (ex: 30s), 0s disables it")
+	cmd.PersistentFlags().BoolVarP(&drifts.DetectOrphans, "detect-orphans", "", false,
+		"when enabled, the objects from the cluster annotated as owned by the release (meta.helm.sh/release-name) "+
+			"that are no longer part of its manifests are reported as orphaned")
+	cmd.PersistentFlags().BoolVarP(&drifts.History, "history", "", false,
+		"when enabled, the live state of every drifted resource is matched against the other revisions of the release, "+
+			"reporting the latest revision it matches (ex: after a rollback gone wrong) or none when it was edited in place")
+	cmd.PersistentFlags().BoolVarP(&drifts.OnlyMissing, "only-missing", "", false,
+		"when enabled, only the resources missing from the cluster (rendered but with no live object) are reported, the rest of the drifts are left out")
 	cmd.PersistentFlags().IntVarP(&drifts.Limit, "limit-threads", "", 0,
 		"limit the number of threads spawned by the plugin for executing the 'kubectl diff' command. "+
 			"This helps in batching tasks efficiently without overwhelming system resources. "+
@@ -83,4 +172,23 @@ func registerDriftFlags(cmd *cobra.Command) {
 func registerDriftAllFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().BoolVarP(&drifts.IsDefaultNamespace, "is-default-namespace", "", false,
 		"set this flag if drifts have to be checked specifically in 'default' namespace")
+	cmd.PersistentFlags().StringArrayVar(&drifts.SkipReleases, "skip-release", nil,
+		"list of helm releases to be skipped for identifying helm drifts, ex: ReleaseName=Namespace | ReleaseName=Namespace")
+}
+
+// Registers flags specific to the table rendered by command, all.
+func registerDriftAllTableFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&drifts.TableDetail, "table-detail", "", pkg.TableDetailRelease,
+		"detail of the table rendered with '--output table', it should be one of release|resource. "+
+			"With 'resource' every resource that is not in sync is listed grouped by its release, instead of a row for every release")
+}
+
+// Registers flags specific to command, serve.
+func registerServeFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&listenAddress, "listen-address", "", pkg.DefaultListenAddress,
+		"address on which the prometheus metrics would be exposed")
+	cmd.PersistentFlags().StringVarP(&metricsPath, "metrics-path", "", pkg.DefaultMetricsPath,
+		"path on which the prometheus metrics would be exposed")
+	cmd.PersistentFlags().DurationVarP(&scanInterval, "interval", "", pkg.DefaultScanInterval,
+		"interval at which the drifts would be identified from all the releases")
 }
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/nikhilsbhat/helm-drift/pkg"
 	"github.com/nikhilsbhat/helm-drift/pkg/errors"
@@ -11,8 +12,13 @@ import (
 )
 
 var (
-	drifts    = pkg.Drift{}
-	cliLogger *logrus.Logger
+	drifts        = pkg.Drift{}
+	cliLogger     *logrus.Logger
+	listenAddress string
+	metricsPath   string
+	scanInterval  time.Duration
+	compareWith   pkg.CompareTarget
+	baselineFile  string
 )
 
 const (
@@ -34,6 +40,10 @@ func getDriftCommands() *cobra.Command {
 	command := new(driftCommands)
 	command.commands = append(command.commands, getRunCommand())
 	command.commands = append(command.commands, getAllCommand())
+	command.commands = append(command.commands, getServeCommand())
+	command.commands = append(command.commands, getFixCommand())
+	command.commands = append(command.commands, getCompareCommand())
+	command.commands = append(command.commands, getBaselineCommand())
 	command.commands = append(command.commands, getVersionCommand())
 
 	return command.prepareCommands()
@@ -50,8 +60,18 @@ func (c *driftCommands) prepareCommands() *cobra.Command {
 	return rootCmd
 }
 
-//nolint:goerr113
 func validateAndSetArgs(cmd *cobra.Command, args []string) error {
+	if err := loadConfig(cmd); err != nil {
+		return err
+	}
+
+	return setArgs(cmd, args)
+}
+
+// setArgs validates the [RELEASE] [CHART] passed and sets them, the config file is expected to be loaded by then.
+//
+//nolint:goerr113
+func setArgs(cmd *cobra.Command, args []string) error {
 	logger := logrus.New()
 	logger.SetLevel(pkg.GetLoglevel(drifts.LogLevel))
 	logger.WithField("helm-drift", true)
@@ -69,7 +89,13 @@ func validateAndSetArgs(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
 
 	if drifts.Revision != 0 && !drifts.FromRelease {
-		cliLogger.Fatalf("the '--revision' flag can only be used when retrieving images from a release, i.e., when the '--from-release' flag is set")
+		return &errors.DriftError{
+			Message: "the '--revision' flag can only be used when retrieving images from a release, i.e., when the '--from-release' flag is set",
+		}
+	}
+
+	if len(args) == 0 {
+		return minArgError
 	}
 
 	drifts.SetRelease(args[0])
@@ -87,7 +113,7 @@ func validateAndSetArgs(cmd *cobra.Command, args []string) error {
 	}
 
 	if len(args) > getArgumentCountRelease {
-		log.Fatalln(fmt.Errorf("%w", oneOfThemError))
+		return oneOfThemError
 	}
 
 	return nil
@@ -0,0 +1,157 @@
+// Package testutil holds the fakes the tests of helm drift share, to identify drifts without kubectl, helm or a cluster.
+package testutil
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/command"
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/sirupsen/logrus"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	k8sTesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+)
+
+// DeploymentResource is the resource of the deployments the fake cluster serves.
+var DeploymentResource = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+// FakeExec reports the diff set on it as the output of 'kubectl diff', without running kubectl,
+// and the output set on it as the output of helm, without running helm.
+// When DiffManifests is set, the manifest diffed is reported as added instead, so the drifts change with the fields ignored.
+// When Hang is set, it behaves like a kubectl that never completes and returns only once its context is done.
+type FakeExec struct {
+	Diff          string
+	Output        string
+	DiffManifests bool
+	Hang          bool
+	Cmd           string
+	Args          []string
+	ctx           context.Context //nolint:containedctx
+}
+
+// Executor returns the command.Executor that runs the commands with the FakeExec, the command set is recorded as Cmd.
+func (exec *FakeExec) Executor() command.Executor {
+	return func(ctx context.Context, cmd string, _ *logrus.Logger) command.Exec {
+		exec.ctx, exec.Cmd = ctx, cmd
+
+		return exec
+	}
+}
+
+func (exec *FakeExec) SetKubeDiffCmd(_, _, _ string, args ...string) {
+	exec.Args = args
+}
+
+func (exec *FakeExec) RunKubeDiffCmd(dvn *deviation.Deviation) (*deviation.Deviation, error) {
+	if exec.Hang {
+		<-exec.ctx.Done()
+
+		return dvn, exec.ctx.Err()
+	}
+
+	if exec.DiffManifests {
+		manifest, err := os.ReadFile(dvn.ManifestPath)
+		if err != nil {
+			return nil, err
+		}
+
+		dvn.HasDrift = true
+		dvn.Deviations = "+" + strings.ReplaceAll(strings.TrimSpace(string(manifest)), "\n", "\n+") + "\n"
+
+		return dvn, nil
+	}
+
+	if len(exec.Diff) != 0 {
+		dvn.HasDrift = true
+		dvn.Deviations = exec.Diff
+	}
+
+	return dvn, nil
+}
+
+func (exec *FakeExec) SetKubeGetCmd(_, _, _ string, _ ...string) {}
+
+func (exec *FakeExec) RunKubeCmd(_ *deviation.Deviation) ([]byte, error) {
+	return nil, nil
+}
+
+func (exec *FakeExec) SetHelmCmd(args ...string) {
+	exec.Args = args
+}
+
+func (exec *FakeExec) RunHelmCmd() ([]byte, error) {
+	return []byte(exec.Output), nil
+}
+
+// NewFakeRESTMapper returns the REST mapper of the fake cluster, which maps the deployments.
+func NewFakeRESTMapper() meta.RESTMapper {
+	restMapper := meta.NewDefaultRESTMapper(nil)
+	restMapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	return restMapper
+}
+
+// NewFakeDynamicClient returns the dynamic client of a fake cluster with the objects passed,
+// on which server-side apply in dry-run mode behaves as it does with the API server.
+func NewFakeDynamicClient(objects ...runtime.Object) *dynamicFake.FakeDynamicClient {
+	dynamicClient := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{DeploymentResource: "DeploymentList"}, objects...)
+	dynamicClient.PrependReactor("patch", "*", DryRunApplyReactor(dynamicClient))
+
+	return dynamicClient
+}
+
+// DryRunApplyReactor has the fake dynamic client behave like the API server on server-side apply in dry-run mode,
+// the object applied is merged on to the one in the cluster, or created when there is none, without persisting it.
+func DryRunApplyReactor(dynamicClient *dynamicFake.FakeDynamicClient) k8sTesting.ReactionFunc {
+	return func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		patch, isPatch := action.(k8sTesting.PatchActionImpl)
+		if !isPatch || patch.GetPatchType() != types.ApplyPatchType || len(patch.PatchOptions.DryRun) == 0 {
+			return false, nil, nil
+		}
+
+		applied := make(map[string]any)
+		if err := yaml.Unmarshal(patch.GetPatch(), &applied); err != nil {
+			return true, nil, err
+		}
+
+		existing, err := dynamicClient.Tracker().Get(patch.GetResource(), patch.GetNamespace(), patch.GetName())
+		if err != nil {
+			if apiErrors.IsNotFound(err) {
+				return true, &unstructured.Unstructured{Object: applied}, nil
+			}
+
+			return true, nil, err
+		}
+
+		merged := existing.(*unstructured.Unstructured).DeepCopy()
+
+		return true, &unstructured.Unstructured{Object: mergeApplied(merged.Object, applied)}, nil
+	}
+}
+
+// mergeApplied merges the fields applied on to the object, the way server-side apply does for the fields of maps.
+func mergeApplied(object, applied map[string]any) map[string]any {
+	for key, value := range applied {
+		appliedMap, isMap := value.(map[string]any)
+		objectMap, wasMap := object[key].(map[string]any)
+
+		if isMap && wasMap {
+			object[key] = mergeApplied(objectMap, appliedMap)
+
+			continue
+		}
+
+		object[key] = value
+	}
+
+	return object
+}
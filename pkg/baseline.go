@@ -0,0 +1,171 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"github.com/thoas/go-funk"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultBaselineFile is the file to which the baseline is saved, when no file is specified.
+const DefaultBaselineFile = ".helm-drift-baseline.yaml"
+
+// Baseline holds the drifts that are known and accepted, drifts matching them are not reported as drifts.
+type Baseline struct {
+	Releases deviation.DriftedReleases `json:"releases,omitempty" yaml:"releases,omitempty"`
+}
+
+// SaveBaseline saves the drifted resources from the report to the file, to be passed as baseline to the later runs.
+// Only the drifted resources are saved, along with their diffs and field level changes.
+func (drift *Drift) SaveBaseline(report *Report, baselineFile string) error {
+	baseline := Baseline{Releases: make(deviation.DriftedReleases, 0)}
+
+	for _, release := range report.Releases {
+		if !release.HasDrift {
+			continue
+		}
+
+		driftedRelease := &deviation.DriftedRelease{Release: release.Release, Namespace: release.Namespace, Chart: release.Chart, HasDrift: true}
+
+		for _, dvn := range release.Deviations {
+			if !dvn.HasDrift {
+				continue
+			}
+
+			driftedRelease.Deviations = append(driftedRelease.Deviations, &deviation.Deviation{
+				HasDrift:   true,
+				Kind:       dvn.Kind,
+				Resource:   dvn.Resource,
+				NameSpace:  dvn.NameSpace,
+				APIVersion: dvn.APIVersion,
+				Status:     dvn.Status,
+				Deviations: dvn.Deviations,
+				Changes:    dvn.Changes,
+			})
+		}
+
+		baseline.Releases = append(baseline.Releases, driftedRelease)
+	}
+
+	out, err := yaml.Marshal(baseline)
+	if err != nil {
+		return &errors.DriftError{Message: fmt.Sprintf("encoding baseline errored with '%v'", err)}
+	}
+
+	if err = os.WriteFile(baselineFile, out, manifestFilePermission); err != nil {
+		return &errors.DriftError{Message: fmt.Sprintf("writing baseline to '%s' errored with '%v'", baselineFile, err)}
+	}
+
+	drift.log.Infof("baseline of %d drifted resources from %d releases saved to '%s'",
+		baseline.Releases.Count(), len(baseline.Releases), baselineFile)
+
+	return nil
+}
+
+// SetBaseline loads the drifts accepted from the baseline file set, drifts are not matched against any baseline when it is not set.
+func (drift *Drift) SetBaseline() error {
+	if len(drift.Baseline) == 0 {
+		drift.baseline = nil
+
+		return nil
+	}
+
+	drift.log.Debugf("loading baseline from '%s'", drift.Baseline)
+
+	content, err := os.ReadFile(drift.Baseline)
+	if err != nil {
+		return &errors.DriftError{Message: fmt.Sprintf("reading baseline '%s' errored with '%v'", drift.Baseline, err)}
+	}
+
+	var baseline Baseline
+	if err = yaml.UnmarshalStrict(content, &baseline); err != nil {
+		return &errors.DriftError{Message: fmt.Sprintf("parsing baseline '%s' errored with '%v'", drift.Baseline, err)}
+	}
+
+	drift.baseline = make(map[string]string)
+
+	for _, release := range baseline.Releases {
+		for _, dvn := range release.Deviations {
+			drift.baseline[baselineKey(release, dvn)] = diffHash(dvn)
+		}
+	}
+
+	return nil
+}
+
+// applyBaseline marks the drifts of the release that match the baseline, by the resource and the hash of its diff, as baselined.
+// Drifts that are new or have changed since the baseline was saved are left as is.
+func (drift *Drift) applyBaseline(release *deviation.DriftedRelease) {
+	if drift.baseline == nil || !release.HasDrift {
+		return
+	}
+
+	for _, dvn := range release.Deviations {
+		if !dvn.HasDrift {
+			continue
+		}
+
+		hash, found := drift.baseline[baselineKey(release, dvn)]
+		if !found || hash != diffHash(dvn) {
+			continue
+		}
+
+		drift.log.Debugf("drifts of '%s' '%s' from release '%s' are accepted by the baseline", dvn.Kind, dvn.Resource, release.Release)
+
+		dvn.HasDrift, dvn.Status = false, deviation.StatusBaselined
+	}
+
+	release.HasDrift = funk.Contains(release.Deviations, func(dvn *deviation.Deviation) bool {
+		return dvn.HasDrift
+	})
+}
+
+func baselineKey(release *deviation.DriftedRelease, dvn *deviation.Deviation) string {
+	return releaseKey(release.Release, release.Namespace) + "/" + manifestKey(dvn.Kind, dvn.Resource)
+}
+
+// diffHash hashes the field level changes of the resource along with its status. The changes are hashed by their path, type,
+// desired and live values, sorted by the path, so that the fields not among the changes (ex: metadata.generation, or the replicas
+// scaled by HPA when suppressed) do not change the hash when they are updated.
+// When the changes of the resource are not identified (ex: missing resources), its diff is hashed instead, leaving out the headers
+// of the diff since they hold the paths of the files diffed (ex: /tmp/LIVE-2874539/v1.ConfigMap.sample.sample) that differ on every run.
+func diffHash(dvn *deviation.Deviation) string {
+	hash := sha256.New()
+	hash.Write([]byte(dvn.Status + "\n"))
+
+	if len(dvn.Changes) != 0 {
+		changes := slices.Clone(dvn.Changes)
+		sort.SliceStable(changes, func(i, j int) bool {
+			return changes[i].Path < changes[j].Path
+		})
+
+		for _, change := range changes {
+			// values are hashed as json, so that the numbers decoded from the baseline (float64) hash the same as the ones from the cluster (int64).
+			desired, _ := json.Marshal(change.Desired)
+			live, _ := json.Marshal(change.Live)
+
+			hash.Write([]byte(strings.Join([]string{change.Path, change.Type, string(desired), string(live)}, "\t") + "\n"))
+		}
+
+		return hex.EncodeToString(hash.Sum(nil))
+	}
+
+	for _, line := range strings.Split(dvn.Deviations, "\n") {
+		if strings.HasPrefix(line, "diff ") || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ") {
+			continue
+		}
+
+		hash.Write([]byte(line + "\n"))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
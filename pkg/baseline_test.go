@@ -0,0 +1,146 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBaselineRelease(replicasDiff string) *deviation.DriftedRelease {
+	return &deviation.DriftedRelease{
+		Release:   "sample",
+		Namespace: "sample",
+		HasDrift:  true,
+		Deviations: []*deviation.Deviation{
+			{
+				Kind:         "Deployment",
+				Resource:     "sample",
+				HasDrift:     true,
+				ManifestPath: "/tmp/sample.Deployment.sample.yaml",
+				Deviations: "diff -u -N /tmp/LIVE-1234/apps.v1.Deployment.sample.sample /tmp/MERGED-1234/apps.v1.Deployment.sample.sample\n" +
+					"--- /tmp/LIVE-1234/apps.v1.Deployment.sample.sample\n+++ /tmp/MERGED-1234/apps.v1.Deployment.sample.sample\n" + replicasDiff,
+			},
+			{Kind: "Service", Resource: "sample"},
+		},
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	baselineFile := filepath.Join(t.TempDir(), DefaultBaselineFile)
+
+	drift := Drift{}
+	drift.SetLogger("error")
+
+	require.NoError(t, drift.SaveBaseline(&Report{Releases: []*deviation.DriftedRelease{
+		newBaselineRelease("@@ -1 +1 @@\n-  replicas: 2\n+  replicas: 1\n"),
+		{Release: "clean", Namespace: "sample", Deviations: []*deviation.Deviation{{Kind: "Service", Resource: "clean"}}},
+	}}, baselineFile))
+
+	content, err := os.ReadFile(baselineFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "clean", "releases and resources with no drifts should not be saved")
+	assert.NotContains(t, string(content), "manifest_path")
+
+	drift.Baseline = baselineFile
+	require.NoError(t, drift.SetBaseline())
+
+	t.Run("should accept the drifts matching the baseline, regardless of the paths diffed", func(t *testing.T) {
+		release := newBaselineRelease("@@ -1 +1 @@\n-  replicas: 2\n+  replicas: 1\n")
+		release.Deviations[0].Deviations = strings.ReplaceAll(release.Deviations[0].Deviations, "1234", "5678")
+
+		drift.applyBaseline(release)
+
+		assert.False(t, release.HasDrift)
+		assert.False(t, release.Deviations[0].HasDrift)
+		assert.Equal(t, deviation.StatusBaselined, release.Deviations[0].Status)
+		assert.Empty(t, release.Deviations[1].Status)
+	})
+
+	t.Run("should report the drifts that have changed since the baseline", func(t *testing.T) {
+		release := newBaselineRelease("@@ -1 +1 @@\n-  replicas: 3\n+  replicas: 1\n")
+
+		drift.applyBaseline(release)
+
+		assert.True(t, release.HasDrift)
+		assert.True(t, release.Deviations[0].HasDrift)
+		assert.Empty(t, release.Deviations[0].Status)
+	})
+
+	t.Run("should report the drifts of the releases not in the baseline", func(t *testing.T) {
+		release := newBaselineRelease("@@ -1 +1 @@\n-  replicas: 2\n+  replicas: 1\n")
+		release.Namespace = "other"
+
+		drift.applyBaseline(release)
+
+		assert.True(t, release.HasDrift)
+	})
+
+	t.Run("should not accept drifts when no baseline is set", func(t *testing.T) {
+		drift := Drift{}
+		drift.SetLogger("error")
+		require.NoError(t, drift.SetBaseline())
+
+		release := newBaselineRelease("@@ -1 +1 @@\n-  replicas: 2\n+  replicas: 1\n")
+		drift.applyBaseline(release)
+
+		assert.True(t, release.HasDrift)
+	})
+
+	t.Run("should error on unknown fields in the baseline", func(t *testing.T) {
+		invalidBaseline := filepath.Join(t.TempDir(), "baseline.yaml")
+		require.NoError(t, os.WriteFile(invalidBaseline, []byte("drifts: []\n"), manifestFilePermission))
+
+		drift := Drift{Baseline: invalidBaseline}
+		drift.SetLogger("error")
+
+		err := drift.SetBaseline()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parsing baseline")
+	})
+}
+
+func TestBaselineChanges(t *testing.T) {
+	newRelease := func(generation, liveReplicas int64) *deviation.DriftedRelease {
+		release := newBaselineRelease(fmt.Sprintf("@@ -1,4 +1,4 @@\n   generation: %d\n-  replicas: %d\n+  replicas: 1\n", generation, liveReplicas))
+		release.Deviations[0].Changes = []*deviation.Change{
+			{Path: "spec.replicas", Type: deviation.ChangeModified, Desired: int64(1), Live: liveReplicas, Manager: "kubectl-edit"},
+			{Path: "metadata.labels.team", Type: deviation.ChangeAdded, Desired: "platform"},
+		}
+
+		return release
+	}
+
+	baselineFile := filepath.Join(t.TempDir(), DefaultBaselineFile)
+
+	drift := Drift{Baseline: baselineFile}
+	drift.SetLogger("error")
+
+	require.NoError(t, drift.SaveBaseline(&Report{Releases: []*deviation.DriftedRelease{newRelease(4, 2)}}, baselineFile))
+	require.NoError(t, drift.SetBaseline())
+
+	t.Run("should accept the drifts whose diff differs only in the generation", func(t *testing.T) {
+		release := newRelease(7, 2)
+		slices.Reverse(release.Deviations[0].Changes)
+
+		drift.applyBaseline(release)
+
+		assert.False(t, release.HasDrift)
+		assert.Equal(t, deviation.StatusBaselined, release.Deviations[0].Status)
+	})
+
+	t.Run("should report the drifts whose changes differ", func(t *testing.T) {
+		release := newRelease(4, 3)
+
+		drift.applyBaseline(release)
+
+		assert.True(t, release.HasDrift)
+		assert.Empty(t, release.Deviations[0].Status)
+	})
+}
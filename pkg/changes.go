@@ -0,0 +1,184 @@
+package pkg
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/fields"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// setChanges identifies the field level changes of the drifted manifest, by comparing the live object against the object
+// the API server would persist if the manifest was applied (server-side apply in dry-run mode), the same way the native diff engine does.
+// Since both are canonicalised by the API server, the values written differently in the manifest (ex: cpu '1000m') are not changes.
+// The manifest is marked as missing, when it has no live object in the cluster.
+// Changes are attributed to the field managers that last updated them on the live object.
+func (drift *Drift) setChanges(ctx context.Context, dvn *deviation.Deviation, nameSpace string, objects *resourceObjects) error {
+	desired, err := readManifest(dvn.ManifestPath)
+	if err != nil {
+		return err
+	}
+
+	if len(desired.Object) == 0 {
+		return nil
+	}
+
+	live, merged, err := drift.dryRunApply(ctx, desired, dvn, nameSpace)
+	if err != nil {
+		return err
+	}
+
+	objects.set(desired, live)
+
+	if live == nil {
+		dvn.Status = deviation.StatusMissing
+	}
+
+	managedFields := managedFieldsOf(live)
+
+	for _, object := range []*unstructured.Unstructured{live, merged} {
+		if err = drift.normalize(object); err != nil {
+			return err
+		}
+	}
+
+	dvn.Changes = objectChanges(merged, live, false)
+	attributeChanges(managedFields, live, dvn.Changes)
+
+	return nil
+}
+
+// resourceObjects holds the manifest of the resource along with its live object from the cluster. The live object is fetched once
+// per resource, by the diff engine or else by the first of the steps that need it, and is shared by the steps that follow.
+type resourceObjects struct {
+	desired *unstructured.Unstructured
+	live    *unstructured.Unstructured
+	fetched bool
+}
+
+func (objects *resourceObjects) set(desired, live *unstructured.Unstructured) {
+	objects.desired, objects.live, objects.fetched = desired, live.DeepCopy(), true
+}
+
+// liveObject returns the manifest of the resource along with its live object, they are fetched from the cluster only when not fetched yet.
+// The live object returned is a copy, so that it could be normalized by the step using it. It is nil, when it does not exist in the cluster.
+func (drift *Drift) liveObject(
+	ctx context.Context, objects *resourceObjects, dvn *deviation.Deviation, nameSpace string,
+) (*unstructured.Unstructured, *unstructured.Unstructured, error) {
+	if !objects.fetched {
+		desired, live, err := drift.fetchLiveObject(ctx, dvn, nameSpace)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		objects.set(desired, live)
+	}
+
+	return objects.desired, objects.live.DeepCopy(), nil
+}
+
+// fetchLiveObject returns the manifest rendered on to disk along with its live object from the cluster, as it is in the cluster.
+// The live object returned is nil, when it does not exist in the cluster.
+func (drift *Drift) fetchLiveObject(
+	ctx context.Context, dvn *deviation.Deviation, nameSpace string,
+) (*unstructured.Unstructured, *unstructured.Unstructured, error) {
+	desired, err := readManifest(dvn.ManifestPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(desired.Object) == 0 {
+		return desired, nil, nil
+	}
+
+	resourceClient, err := drift.getResourceClient(desired, nameSpace)
+	if err != nil {
+		return desired, nil, err
+	}
+
+	live, err := resourceClient.Get(ctx, desired.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			return desired, nil, nil
+		}
+
+		return desired, nil, err
+	}
+
+	return desired, live, nil
+}
+
+// objectChanges returns the changes between the desired and the live object, leaving out the fields
+// that the API server updates on every write. Values of secrets are masked.
+func objectChanges(desired, live *unstructured.Unstructured, desiredOnly bool) []*deviation.Change {
+	if desired == nil || live == nil {
+		return nil
+	}
+
+	desired, live = desired.DeepCopy(), live.DeepCopy()
+
+	for _, object := range []*unstructured.Unstructured{desired, live} {
+		unstructured.RemoveNestedField(object.Object, "metadata", "managedFields")
+		unstructured.RemoveNestedField(object.Object, "metadata", "resourceVersion")
+		unstructured.RemoveNestedField(object.Object, "metadata", "generation")
+	}
+
+	isSecret := desired.GetKind() == "Secret"
+	if isSecret && desiredOnly {
+		encodeSecretStringData(desired)
+	}
+
+	changes := fields.Compare(desired.Object, live.Object, desiredOnly)
+
+	if isSecret {
+		maskSecretChanges(changes)
+	}
+
+	return changes
+}
+
+// encodeSecretStringData moves the stringData of the secret manifest under data, the way the API server persists it.
+func encodeSecretStringData(secret *unstructured.Unstructured) {
+	stringData, found, err := unstructured.NestedStringMap(secret.Object, "stringData")
+	if !found || err != nil {
+		return
+	}
+
+	data, _, _ := unstructured.NestedMap(secret.Object, "data")
+	if data == nil {
+		data = make(map[string]any, len(stringData))
+	}
+
+	for key, value := range stringData {
+		data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+
+	_ = unstructured.SetNestedMap(secret.Object, data, "data")
+	unstructured.RemoveNestedField(secret.Object, "stringData")
+}
+
+func maskSecretChanges(changes []*deviation.Change) {
+	for _, change := range changes {
+		if !strings.HasPrefix(change.Path, "data") && !strings.HasPrefix(change.Path, "stringData") {
+			continue
+		}
+
+		change.Desired, change.Live = maskSecretValue(change.Desired, secretMaskAfter), maskSecretValue(change.Live, secretMaskBefore)
+	}
+}
+
+func maskSecretValue(value any, mask string) any {
+	switch typed := value.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		return fmt.Sprintf("%s (%d keys)", secretMask, len(typed))
+	default:
+		return mask
+	}
+}
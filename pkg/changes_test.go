@@ -0,0 +1,110 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/internal/testutil"
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+func TestObjectChanges(t *testing.T) {
+	t.Run("skips fields updated by api server on every write", func(t *testing.T) {
+		live := newDeployment(2)
+		live.SetResourceVersion("10")
+		live.SetGeneration(3)
+
+		changes := objectChanges(newDeployment(1), live, false)
+
+		assert.Equal(t, []*deviation.Change{
+			{Path: "spec.replicas", Type: deviation.ChangeModified, Desired: float64(1), Live: float64(2)},
+		}, changes)
+	})
+
+	t.Run("masks secret values", func(t *testing.T) {
+		desired := &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]any{"name": "sample"},
+			"stringData": map[string]any{"password": "desired", "user": "admin"},
+		}}
+		live := &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]any{"name": "sample"},
+			"data":       map[string]any{"password": "bGl2ZQ==", "user": "YWRtaW4="},
+		}}
+
+		changes := objectChanges(desired, live, true)
+
+		assert.Equal(t, []*deviation.Change{
+			{Path: "data.password", Type: deviation.ChangeModified, Desired: secretMaskAfter, Live: secretMaskBefore},
+		}, changes)
+	})
+
+	t.Run("no changes without live object", func(t *testing.T) {
+		assert.Nil(t, objectChanges(newDeployment(1), nil, false))
+	})
+}
+
+func TestSetChanges(t *testing.T) {
+	// newCPUDeployment returns the Deployment 'sample' whose container requests the cpu passed.
+	newCPUDeployment := func(replicas int64, cpu string) *unstructured.Unstructured {
+		deployment := newDeployment(replicas)
+		_ = unstructured.SetNestedSlice(deployment.Object, []any{
+			map[string]any{"name": "app", "resources": map[string]any{"requests": map[string]any{"cpu": cpu}}},
+		}, "spec", "template", "spec", "containers")
+
+		return deployment
+	}
+
+	// newDriftApplying returns Drift talking to a fake cluster with the live object passed,
+	// whose dry-run apply returns the merged object or the error passed.
+	newDriftApplying := func(live, merged *unstructured.Unstructured, err error) *Drift {
+		dynamicClient := testutil.NewFakeDynamicClient(live)
+		dynamicClient.PrependReactor("patch", "*", func(_ k8sTesting.Action) (bool, runtime.Object, error) {
+			return true, merged, err
+		})
+
+		drift := New(append([]Option{WithDynamicClient(dynamicClient)}, newFakeClusterOptions()...)...)
+		drift.SetLogger("error")
+
+		return drift
+	}
+
+	t.Run("values canonicalised by the api server are not changes", func(t *testing.T) {
+		drift := newDriftApplying(newCPUDeployment(2, "1"), newCPUDeployment(1, "1"), nil)
+
+		dvn := &deviation.Deviation{
+			Kind: "Deployment", Resource: "sample", HasDrift: true, ManifestPath: writeManifest(t, newCPUDeployment(1, "1000m").Object),
+		}
+		require.NoError(t, drift.setChanges(t.Context(), dvn, "sample", new(resourceObjects)))
+
+		assert.Equal(t, []string{"spec.replicas"}, changePaths(dvn.Changes))
+		assert.Empty(t, dvn.Status)
+	})
+
+	t.Run("marks the manifest missing when it has no live object", func(t *testing.T) {
+		drift := New(newFakeClusterOptions()...)
+		drift.SetLogger("error")
+
+		dvn := &deviation.Deviation{Kind: "Deployment", Resource: "sample", HasDrift: true, ManifestPath: writeManifest(t, newDeployment(1).Object)}
+		require.NoError(t, drift.setChanges(t.Context(), dvn, "sample", new(resourceObjects)))
+
+		assert.Equal(t, deviation.StatusMissing, dvn.Status)
+		assert.Empty(t, dvn.Changes)
+	})
+
+	t.Run("errors when the dry-run apply fails", func(t *testing.T) {
+		drift := newDriftApplying(newDeployment(2), nil, errors.New("forbidden"))
+
+		dvn := &deviation.Deviation{Kind: "Deployment", Resource: "sample", HasDrift: true, ManifestPath: writeManifest(t, newDeployment(1).Object)}
+		require.ErrorContains(t, drift.setChanges(t.Context(), dvn, "sample", new(resourceObjects)),
+			"dry-run apply of 'Deployment' 'sample' errored with 'forbidden'")
+	})
+}
@@ -9,23 +9,29 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-// Exec implements methods that set's and run's the kubectl command.
+// Exec implements methods that set's and run's the kubectl and helm commands.
 type Exec interface {
 	SetKubeDiffCmd(kubeConfig string, kubeContext string, namespace string, args ...string)
 	RunKubeDiffCmd(deviation *deviation.Deviation) (*deviation.Deviation, error)
 	SetKubeGetCmd(kubeConfig string, kubeContext string, namespace string, args ...string)
 	RunKubeCmd(deviation *deviation.Deviation) ([]byte, error)
+	SetHelmCmd(args ...string)
+	RunHelmCmd() ([]byte, error)
 }
 
+// Executor returns the Exec used to run the command, NewCommand is the Executor helm drift uses by default.
+// Replacing it allows the kubectl and helm commands to be run differently, or not at all (ex: in tests).
+type Executor func(ctx context.Context, cmd string, logger *log.Logger) Exec
+
 type command struct {
 	baseCmd *exec.Cmd
 	log     *log.Logger
 }
 
-// NewCommand returns new instance of Exec.
-func NewCommand(cmd string, logger *log.Logger) Exec {
+// NewCommand returns new instance of Exec, the command is killed if the context is done before it completes.
+func NewCommand(ctx context.Context, cmd string, logger *log.Logger) Exec {
 	commandClient := command{
-		baseCmd: exec.CommandContext(context.Background(), cmd),
+		baseCmd: exec.CommandContext(ctx, cmd),
 		log:     logger,
 	}
 
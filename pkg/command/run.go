@@ -45,3 +45,10 @@ func (cmd *command) RunKubeCmd(deviation *deviation.Deviation) ([]byte, error) {
 
 	return out, nil
 }
+
+// RunHelmCmd runs the helm command and returns its output, the error holds what the command wrote to stderr when it fails.
+func (cmd *command) RunHelmCmd() ([]byte, error) {
+	cmd.log.Debugf("envionment variables that would be used: %v", cmd.baseCmd.Environ())
+
+	return cmd.baseCmd.Output()
+}
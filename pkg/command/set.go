@@ -26,6 +26,14 @@ func (cmd *command) SetKubeGetCmd(kubeConfig string, kubeContext string, namespa
 	cmd.setKubeCmd("get", kubeConfig, kubeContext, namespace, args...)
 }
 
+// SetHelmCmd sets the helm command with the arguments passed (ex: template release chart --version 1.0.0).
+func (cmd *command) SetHelmCmd(args ...string) {
+	cmd.baseCmd.Env = os.Environ()
+	cmd.baseCmd.Args = append(cmd.baseCmd.Args, args...)
+
+	cmd.log.Debugf("running command '%s'", cmd.baseCmd.String())
+}
+
 func (cmd *command) getNamespace(nameSpace string) string {
 	return fmt.Sprintf("-n=%s", nameSpace)
 }
@@ -8,7 +8,7 @@ import (
 )
 
 func TestSetKubeDiffCmd(t *testing.T) {
-	cmd := NewCommand("kubectl", logrus.New()).(*command)
+	cmd := NewCommand(t.Context(), "kubectl", logrus.New()).(*command)
 
 	cmd.SetKubeDiffCmd("/tmp/kubeconfig", "kind-kind", "sample", "-f=manifest.yaml")
 
@@ -23,13 +23,21 @@ func TestSetKubeDiffCmd(t *testing.T) {
 }
 
 func TestSetKubeGetCmd(t *testing.T) {
-	cmd := NewCommand("kubectl", logrus.New()).(*command)
+	cmd := NewCommand(t.Context(), "kubectl", logrus.New()).(*command)
 
 	cmd.SetKubeGetCmd("", "", "default", "pods")
 
 	assert.Equal(t, []string{"kubectl", "get", "pods", "-n=default"}, cmd.baseCmd.Args)
 }
 
+func TestSetHelmCmd(t *testing.T) {
+	cmd := NewCommand(t.Context(), "helm", logrus.New()).(*command)
+
+	cmd.SetHelmCmd("template", "release", "chart", "--version", "1.0.0")
+
+	assert.Equal(t, []string{"helm", "template", "release", "chart", "--version", "1.0.0"}, cmd.baseCmd.Args)
+}
+
 func TestGetContext(t *testing.T) {
 	assert.Equal(t, []string{"--context=ctx"}, getContext("", "ctx"))
 	assert.Equal(t, []string{"--kubeconfig=/tmp/config"}, getContext("/tmp/config", ""))
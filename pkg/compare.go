@@ -0,0 +1,228 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"github.com/nikhilsbhat/helm-drift/pkg/fields"
+	"github.com/thoas/go-funk"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const currentKubeContext = "current-context"
+
+// CompareTarget selects the release against which the release set is compared, fields left empty default to the ones of the release set.
+type CompareTarget struct {
+	// KubeContext is the context from the kubeconfig, of the cluster where the release is.
+	KubeContext string
+	// Namespace of the release.
+	Namespace string
+	// Release is the name of the helm release.
+	Release string
+}
+
+// compareManifest is a manifest of the release to be compared, indexed by its kind and name.
+type compareManifest struct {
+	key      string
+	template *deviation.Deviation
+	object   *unstructured.Unstructured
+}
+
+// Compare diffs the manifests of the release set against the manifests of the release from the target, instead of against the live state.
+// It helps in finding why the same release behaves differently across clusters (ex: staging and production) or namespaces.
+// Resources are matched by their kind and name, the resources that differ are reported as drifted, along with the changes on them
+// with Desired being the value from the release set and Live the value from the target. Resources present in only one of the releases
+// are reported as missing (from the target) or extra (only in the target). The report could be rendered with Render.
+// The comparison is abandoned once the context is done, or when it takes longer than the Timeout set.
+func (drift *Drift) Compare(ctx context.Context, target CompareTarget) (*Report, error) {
+	startTime := time.Now()
+
+	ctx, cancel := withTimeout(ctx, drift.Timeout)
+	defer cancel()
+
+	if len(target.KubeContext) == 0 {
+		target.KubeContext = drift.kubeContext
+	}
+
+	if len(target.Namespace) == 0 {
+		target.Namespace = drift.namespace
+	}
+
+	if len(target.Release) == 0 {
+		target.Release = drift.release
+	}
+
+	source := CompareTarget{KubeContext: drift.kubeContext, Namespace: drift.namespace, Release: drift.release}
+	if source == target {
+		return nil, &errors.DriftError{Message: "release to compare against should differ either by the kube context, the namespace or the name of the release"}
+	}
+
+	sourceManifests, err := drift.compareManifests(ctx, source, drift.Revision)
+	if err != nil {
+		return nil, err
+	}
+
+	targetManifests, err := drift.compareManifests(ctx, target, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	drift.against = target.String()
+
+	deviations, err := drift.compareReleases(ctx, source, target, sourceManifests, targetManifests)
+	if err != nil {
+		return nil, err
+	}
+
+	driftedRelease := &deviation.DriftedRelease{Release: drift.release, Namespace: drift.namespace, Deviations: deviations}
+	driftedRelease.HasDrift = funk.Contains(deviations, func(dvn *deviation.Deviation) bool {
+		return dvn.HasDrift
+	})
+
+	drift.timeSpent = time.Since(startTime).Seconds()
+
+	return &Report{Releases: []*deviation.DriftedRelease{driftedRelease}, TimeSpent: drift.timeSpent}, nil
+}
+
+// String describes the release from the target, to be used in the messages rendered.
+func (target CompareTarget) String() string {
+	kubeContext := target.KubeContext
+	if len(kubeContext) == 0 {
+		kubeContext = currentKubeContext
+	}
+
+	return fmt.Sprintf("release '%s' from namespace '%s' of kube context '%s'", target.Release, target.Namespace, kubeContext)
+}
+
+// label identifies the release from the target, in the unified diffs.
+func (target CompareTarget) label() string {
+	kubeContext := target.KubeContext
+	if len(kubeContext) == 0 {
+		kubeContext = currentKubeContext
+	}
+
+	return fmt.Sprintf("%s/%s/%s", kubeContext, target.Namespace, target.Release)
+}
+
+// compareReleases diffs the manifests of both the releases, resources are ordered as they are in the source release
+// followed by the ones present only in the target release.
+func (drift *Drift) compareReleases(
+	ctx context.Context, source, target CompareTarget, sourceManifests, targetManifests []*compareManifest,
+) ([]*deviation.Deviation, error) {
+	targetIndex := make(map[string]*compareManifest, len(targetManifests))
+	for _, manifest := range targetManifests {
+		targetIndex[manifest.key] = manifest
+	}
+
+	sourceIndex := make(map[string]struct{}, len(sourceManifests))
+	deviations := make([]*deviation.Deviation, 0, len(sourceManifests))
+
+	for _, sourceManifest := range sourceManifests {
+		sourceIndex[sourceManifest.key] = struct{}{}
+
+		dvn := sourceManifest.template
+
+		if err := ctx.Err(); err != nil {
+			return nil, &errors.DriftError{Message: fmt.Sprintf("comparing '%s' '%s' errored with '%v'", dvn.Kind, dvn.Resource, err)}
+		}
+
+		targetManifest, found := targetIndex[sourceManifest.key]
+		if !found {
+			dvn.HasDrift, dvn.Status = true, deviation.StatusMissing
+			deviations = append(deviations, dvn)
+
+			continue
+		}
+
+		diff, err := unifiedDiff(targetManifest.object, sourceManifest.object, target.label(), source.label())
+		if err != nil {
+			return nil, &errors.DriftError{Message: fmt.Sprintf("comparing '%s' '%s' errored with '%v'", dvn.Kind, dvn.Resource, err)}
+		}
+
+		if len(diff) != 0 {
+			drift.log.Debugf("found differences in '%s' '%s'", dvn.Kind, dvn.Resource)
+
+			dvn.HasDrift, dvn.Deviations = true, diff
+			dvn.Changes = fields.Compare(sourceManifest.object.Object, targetManifest.object.Object, false)
+
+			if dvn.Kind == "Secret" {
+				maskSecretChanges(dvn.Changes)
+			}
+		}
+
+		deviations = append(deviations, dvn)
+	}
+
+	for _, targetManifest := range targetManifests {
+		if _, found := sourceIndex[targetManifest.key]; found {
+			continue
+		}
+
+		dvn := targetManifest.template
+		dvn.HasDrift, dvn.Status = true, deviation.StatusExtra
+		deviations = append(deviations, dvn)
+	}
+
+	return deviations, nil
+}
+
+// compareManifests fetches the manifests of the release from the target, and parses them.
+func (drift *Drift) compareManifests(ctx context.Context, target CompareTarget, revision int) ([]*compareManifest, error) {
+	manifest, err := drift.getReleaseManifest(ctx, target.KubeContext, target.Namespace, target.Release, revision)
+	if err != nil {
+		return nil, &errors.DriftError{Message: fmt.Sprintf("fetching manifests of %s errored with '%v'", target, err)}
+	}
+
+	return drift.parseCompareManifests(target, manifest)
+}
+
+// parseCompareManifests splits the manifest of the release from the target, and parses the ones selected by the filters set.
+// The namespaces of the manifests are left out so that the releases from different namespaces could be compared, and
+// the fields ignored by the ignore rules are removed.
+func (drift *Drift) parseCompareManifests(target CompareTarget, manifest []byte) ([]*compareManifest, error) {
+	manifests, err := drift.filterManifests(drift.getTemplates(manifest))
+	if err != nil {
+		return nil, &errors.DriftError{Message: fmt.Sprintf("filtering manifests of %s errored with '%v'", target, err)}
+	}
+
+	compareManifests := make([]*compareManifest, 0, len(manifests))
+
+	for _, manifest := range manifests {
+		template, err := NewHelmTemplate(manifest).Get(drift.log)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest, _, err = drift.applyIgnoreRules(manifest, template, target.Release, target.Namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		if manifest, err = drift.normalizeManifest(manifest, template); err != nil {
+			return nil, err
+		}
+
+		object := make(map[string]any)
+		if err = yaml.Unmarshal([]byte(manifest), &object); err != nil {
+			return nil, &errors.DriftError{Message: fmt.Sprintf("parsing manifest of '%s' '%s' errored with '%v'", template.Kind, template.Resource, err)}
+		}
+
+		if len(object) == 0 {
+			continue
+		}
+
+		unstructured.RemoveNestedField(object, "metadata", "namespace")
+
+		compareManifests = append(compareManifests, &compareManifest{
+			key:      manifestKey(template.Kind, template.Resource),
+			template: template,
+			object:   &unstructured.Unstructured{Object: object},
+		})
+	}
+
+	return compareManifests, nil
+}
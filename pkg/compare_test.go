@@ -0,0 +1,140 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func newCompareManifest(t *testing.T, objects ...map[string]any) []byte {
+	t.Helper()
+
+	var manifest string
+
+	for _, object := range objects {
+		out, err := yaml.Marshal(object)
+		require.NoError(t, err)
+
+		manifest += "---\n# Source: sample/templates/manifest.yaml\n" + string(out)
+	}
+
+	return []byte(manifest)
+}
+
+func TestCompareReleases(t *testing.T) {
+	source := CompareTarget{KubeContext: "staging", Namespace: "sample", Release: "sample"}
+	target := CompareTarget{KubeContext: "production", Namespace: "sample-prod", Release: "sample"}
+
+	configMap := func(name, value string) map[string]any {
+		return map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"name": name, "namespace": "sample"},
+			"data":       map[string]any{"key": value},
+		}
+	}
+
+	drift := Drift{Regex: TemplateRegex}
+	drift.SetLogger("error")
+
+	sourceManifests, err := drift.parseCompareManifests(source,
+		newCompareManifest(t, newDeployment(1).Object, configMap("same", "value"), configMap("source-only", "value")))
+	require.NoError(t, err)
+
+	prodConfigMap := configMap("same", "value")
+	prodConfigMap["metadata"].(map[string]any)["namespace"] = "sample-prod"
+
+	targetManifests, err := drift.parseCompareManifests(target,
+		newCompareManifest(t, newDeployment(3).Object, prodConfigMap, configMap("target-only", "value")))
+	require.NoError(t, err)
+
+	deviations, err := drift.compareReleases(t.Context(), source, target, sourceManifests, targetManifests)
+	require.NoError(t, err)
+	require.Len(t, deviations, 4)
+
+	t.Run("should report the resources differing between the releases", func(t *testing.T) {
+		dvn := deviations[0]
+
+		assert.Equal(t, "Deployment", dvn.Kind)
+		assert.True(t, dvn.HasDrift)
+		assert.Empty(t, dvn.Status)
+		assert.Contains(t, dvn.Deviations, "--- production/sample-prod/sample/apps.v1.Deployment.sample\n")
+		assert.Contains(t, dvn.Deviations, "+++ staging/sample/sample/apps.v1.Deployment.sample\n")
+		assert.Contains(t, dvn.Deviations, "-  replicas: 3\n+  replicas: 1")
+		assert.Equal(t, []*deviation.Change{{Path: "spec.replicas", Type: deviation.ChangeModified, Desired: float64(1), Live: float64(3)}}, dvn.Changes)
+	})
+
+	t.Run("should not report the resources differing only by the namespace", func(t *testing.T) {
+		assert.Equal(t, "same", deviations[1].Resource)
+		assert.False(t, deviations[1].HasDrift)
+	})
+
+	t.Run("should report the resources present only in one of the releases", func(t *testing.T) {
+		assert.Equal(t, "source-only", deviations[2].Resource)
+		assert.Equal(t, deviation.StatusMissing, deviations[2].Status)
+		assert.True(t, deviations[2].HasDrift)
+
+		assert.Equal(t, "target-only", deviations[3].Resource)
+		assert.Equal(t, deviation.StatusExtra, deviations[3].Status)
+		assert.True(t, deviations[3].HasDrift)
+	})
+}
+
+func TestCompareCancelled(t *testing.T) {
+	drift := Drift{Regex: TemplateRegex}
+	drift.SetLogger("error")
+	drift.SetRelease("sample")
+	drift.SetNamespace("sample")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := drift.Compare(ctx, CompareTarget{Namespace: "sample-prod"})
+	require.ErrorContains(t, err, "fetching manifests of release 'sample' from namespace 'sample'")
+	assert.ErrorContains(t, err, context.Canceled.Error())
+
+	t.Run("should stop comparing the resources once the context is done", func(t *testing.T) {
+		source := CompareTarget{Namespace: "sample", Release: "sample"}
+		manifests, err := drift.parseCompareManifests(source, newCompareManifest(t, newDeployment(1).Object))
+		require.NoError(t, err)
+
+		_, err = drift.compareReleases(ctx, source, CompareTarget{Namespace: "sample-prod", Release: "sample"}, manifests, manifests)
+		assert.ErrorContains(t, err, "comparing 'Deployment' 'sample' errored with 'context canceled'")
+	})
+}
+
+func TestCompareSameRelease(t *testing.T) {
+	drift := Drift{}
+	drift.SetLogger("error")
+	drift.SetRelease("sample")
+	drift.SetNamespace("sample")
+
+	_, err := drift.Compare(t.Context(), CompareTarget{Namespace: "sample"})
+	assert.EqualError(t, err, "release to compare against should differ either by the kube context, the namespace or the name of the release")
+}
+
+func TestRenderCompare(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	drift := Drift{NoColor: true, against: CompareTarget{KubeContext: "production", Namespace: "sample", Release: "sample"}.String()}
+	drift.SetLogger("error")
+	drift.SetWriter(buffer)
+
+	require.NoError(t, drift.Render(&Report{Releases: []*deviation.DriftedRelease{{
+		Release:  "sample",
+		HasDrift: true,
+		Deviations: []*deviation.Deviation{
+			{Kind: "ConfigMap", Resource: "source-only", HasDrift: true, Status: deviation.StatusMissing},
+			{Kind: "ConfigMap", Resource: "target-only", HasDrift: true, Status: deviation.StatusExtra},
+		},
+	}}}))
+
+	assert.Contains(t, buffer.String(), "Compared against                       : release 'sample' from namespace 'sample' of kube context 'production'")
+	assert.Contains(t, buffer.String(), "Missing from release 'sample' from namespace 'sample' of kube context 'production': 'ConfigMap' 'source-only'")
+	assert.Contains(t, buffer.String(), "Only in release 'sample' from namespace 'sample' of kube context 'production': 'ConfigMap' 'target-only'")
+	assert.Contains(t, buffer.String(), "Total number of extra resources        : 1")
+}
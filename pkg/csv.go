@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+)
+
+// csvRow is the row of the CSV report, for every resource of the releases.
+type csvRow struct {
+	Release    string `csv:"release"`
+	Namespace  string `csv:"namespace"`
+	Chart      string `csv:"chart"`
+	Kind       string `csv:"kind"`
+	Name       string `csv:"name"`
+	APIVersion string `csv:"api_version"`
+	Drifted    string `csv:"drifted"`
+	Changes    string `csv:"changes"`
+}
+
+// toCSV renders the drifts with the CSV renderer, a row for every resource of the releases.
+func (drift *Drift) toCSV(drifts []*deviation.DriftedRelease) error {
+	rows := make([]*csvRow, 0)
+
+	for _, release := range drifts {
+		for _, dvn := range release.Deviations {
+			rows = append(rows, &csvRow{
+				Release:    release.Release,
+				Namespace:  drift.setNameSpace(release, dvn),
+				Chart:      release.Chart,
+				Kind:       dvn.Kind,
+				Name:       dvn.Resource,
+				APIVersion: dvn.APIVersion,
+				Drifted:    dvn.Drifted(),
+				Changes:    changeSummary(dvn.Changes),
+			})
+		}
+	}
+
+	if err := drift.flush(); err != nil {
+		return err
+	}
+
+	return drift.renderer.Render(rows)
+}
+
+// changeSummary summarises the field level changes in a single cell (ex: 'spec.replicas (modified); metadata.labels.team (added)').
+func changeSummary(changes []*deviation.Change) string {
+	summary := make([]string, 0, len(changes))
+
+	for _, change := range changes {
+		summary = append(summary, fmt.Sprintf("%s (%s)", change.Path, change.Type))
+	}
+
+	return strings.Join(summary, "; ")
+}
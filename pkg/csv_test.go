@@ -0,0 +1,34 @@
+package pkg
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderCSV(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	drift := Drift{OutputFormat: "csv"}
+	drift.SetLogger("error")
+	drift.SetWriter(buffer)
+
+	releases := newReportedReleases()
+	releases[0].Chart = "sample-0.1.0"
+	releases[0].Deviations[0].APIVersion = "apps/v1"
+	releases[0].Deviations[0].Changes = []*deviation.Change{
+		{Path: "spec.replicas", Type: deviation.ChangeModified},
+		{Path: "metadata.labels.team", Type: deviation.ChangeAdded},
+	}
+
+	require.NoError(t, drift.Render(&Report{Releases: releases}))
+
+	assert.Equal(t, `release,namespace,chart,kind,name,api_version,drifted,changes
+sample,sample,sample-0.1.0,Deployment,sample,apps/v1,YES,spec.replicas (modified); metadata.labels.team (added)
+sample,sample,sample-0.1.0,Service,sample,,NO,
+sample,sample,sample-0.1.0,ConfigMap,old,,ORPHANED,
+other,other,,Secret,credentials,,TIMED-OUT,
+`, buffer.String())
+}
@@ -0,0 +1,307 @@
+package pkg
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"github.com/nikhilsbhat/helm-drift/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/openapi"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultsSchemaCluster resolves the defaults of the fields from the OpenAPI schema served by the cluster,
+	// falling back to the bundled schema for the fields the cluster's schema declares no default for.
+	DefaultsSchemaCluster = "cluster"
+	// DefaultsSchemaBundled resolves the defaults of the fields only from the schema bundled with helm drift, without reaching the cluster.
+	DefaultsSchemaBundled = "bundled"
+
+	suppressedByDefault = "default"
+	openAPISchemaRef    = "#/components/schemas/"
+)
+
+//go:embed defaults_schema.yaml
+var bundledDefaultsSchema []byte
+
+// openAPIDocument is the part of an OpenAPI v3 document, that is needed to resolve the defaults of the fields.
+type openAPIDocument struct {
+	Components struct {
+		Schemas map[string]*openAPISchema `json:"schemas,omitempty"`
+	} `json:"components"`
+	kinds map[schema.GroupVersionKind]*openAPISchema
+}
+
+type openAPISchema struct {
+	Ref        string                    `json:"$ref,omitempty"`
+	AllOf      []*openAPISchema          `json:"allOf,omitempty"`
+	Properties map[string]*openAPISchema `json:"properties,omitempty"`
+	Items      *openAPISchema            `json:"items,omitempty"`
+	Default    any                       `json:"default,omitempty"`
+	Kinds      []schema.GroupVersionKind `json:"x-kubernetes-group-version-kind,omitempty"`
+}
+
+// WithOpenAPIClient sets the client used to fetch the OpenAPI schema of the cluster, instead of the one from the kubernetes client.
+func WithOpenAPIClient(client openapi.Client) Option {
+	return func(drift *Drift) {
+		drift.openAPIClient = client
+	}
+}
+
+// SetDefaultsSchema loads the schema the defaults of the fields are resolved from, when the changes on the defaulted fields are to be ignored.
+func (drift *Drift) SetDefaultsSchema() error {
+	if !drift.IgnoreDefaults {
+		return nil
+	}
+
+	switch drift.DefaultsSchema {
+	case DefaultsSchemaCluster, DefaultsSchemaBundled, "":
+	default:
+		return &errors.DriftError{
+			Message: fmt.Sprintf("helm drift does not support defaults schema '%s', it should be one of cluster|bundled", drift.DefaultsSchema),
+		}
+	}
+
+	bundled, err := parseOpenAPIDocument(bundledDefaultsSchema)
+	if err != nil {
+		return &errors.DriftError{Message: fmt.Sprintf("parsing the bundled defaults schema errored with '%v'", err)}
+	}
+
+	drift.defaultsMu.Lock()
+	drift.bundledSchema, drift.clusterSchemas = bundled, make(map[string]*openAPIDocument)
+	drift.defaultsMu.Unlock()
+
+	return nil
+}
+
+// suppressDefaults moves the changes of the drifted resource on the fields that are not set in the manifest, and whose live value
+// is the default of the field, to suppressed. Such fields are set by the API server while defaulting, and are not drifts.
+// Since the changes are identified against the object defaulted by the API server, the fields left out of the manifest are
+// identified by comparing the manifest as rendered against the whole of the live object instead.
+// The resource is no longer considered drifted when all of its changes are suppressed.
+func (drift *Drift) suppressDefaults(ctx context.Context, dvn *deviation.Deviation, nameSpace string, objects *resourceObjects) error {
+	if !drift.IgnoreDefaults || drift.bundledSchema == nil || !dvn.HasDrift || len(dvn.Status) != 0 || len(dvn.Changes) == 0 {
+		return nil
+	}
+
+	desired, live, err := drift.liveObject(ctx, objects, dvn, nameSpace)
+	if err != nil {
+		return &errors.DriftError{
+			Message: fmt.Sprintf("identifying fields set to their defaults of '%s' '%s' errored with '%v'", dvn.Kind, dvn.Resource, err),
+		}
+	}
+
+	if err = drift.normalize(live); err != nil {
+		return err
+	}
+
+	drift.suppressDefaultedChanges(dvn, objectChanges(desired, live, false))
+
+	return nil
+}
+
+// suppressDefaultedChanges suppresses the changes of the resource on the fields set to their defaults, the fields left out of the manifest
+// are the ones unset in the manifest changes passed (changes between the manifest as rendered and the live object).
+// Only the changes of the resource are suppressed, fields left out of the manifest that have not drifted are not reported.
+func (drift *Drift) suppressDefaultedChanges(dvn *deviation.Deviation, manifestChanges []*deviation.Change) {
+	gvk := schema.FromAPIVersionAndKind(dvn.APIVersion, dvn.Kind)
+
+	defaulted := make(map[string]struct{})
+
+	for _, change := range manifestChanges {
+		if drift.isDefaulted(gvk, change) {
+			defaulted[change.Path] = struct{}{}
+		}
+	}
+
+	changes := make([]*deviation.Change, 0, len(dvn.Changes))
+
+	for _, change := range dvn.Changes {
+		if _, found := defaulted[change.Path]; !found && !drift.isDefaulted(gvk, change) {
+			changes = append(changes, change)
+
+			continue
+		}
+
+		drift.suppressDefault(dvn, change)
+	}
+
+	dvn.Changes = changes
+
+	if len(changes) == 0 {
+		dvn.HasDrift, dvn.Deviations = false, ""
+	}
+}
+
+// isDefaulted returns true when the field changed is not set in the manifest, and its live value is the default of the field.
+func (drift *Drift) isDefaulted(gvk schema.GroupVersionKind, change *deviation.Change) bool {
+	if !isUnset(change.Desired) {
+		return false
+	}
+
+	defaultValue, found := drift.defaultOf(gvk, change.Path)
+
+	return found && fields.Equal(defaultValue, change.Live)
+}
+
+func (drift *Drift) suppressDefault(dvn *deviation.Deviation, change *deviation.Change) {
+	drift.log.Debugf("ignoring change on field '%s' of '%s' '%s' as it is set to its default", change.Path, dvn.Kind, dvn.Resource)
+
+	change.SuppressedBy = suppressedByDefault
+	dvn.Suppressed = append(dvn.Suppressed, change)
+}
+
+// defaultOf returns the default of the field at the path from the resource of the kind, from the cluster's schema when set to,
+// and then from the bundled schema.
+func (drift *Drift) defaultOf(gvk schema.GroupVersionKind, fieldPath string) (any, bool) {
+	if drift.DefaultsSchema != DefaultsSchemaBundled {
+		if defaultValue, found := drift.clusterSchema(gvk.GroupVersion()).defaultOf(gvk, fieldPath); found {
+			return defaultValue, true
+		}
+	}
+
+	return drift.bundledSchema.defaultOf(gvk, fieldPath)
+}
+
+// clusterSchema fetches the OpenAPI schema of the group version from the cluster, schemas are fetched once and cached.
+// It is nil when the schema could not be fetched, so that the defaults are resolved only from the bundled schema.
+func (drift *Drift) clusterSchema(groupVersion schema.GroupVersion) *openAPIDocument {
+	drift.defaultsMu.Lock()
+	defer drift.defaultsMu.Unlock()
+
+	schemaPath := path.Join("apis", groupVersion.Group, groupVersion.Version)
+	if len(groupVersion.Group) == 0 {
+		schemaPath = path.Join("api", groupVersion.Version)
+	}
+
+	if document, found := drift.clusterSchemas[schemaPath]; found {
+		return document
+	}
+
+	document, err := drift.fetchClusterSchema(schemaPath)
+	if err != nil {
+		drift.log.Warnf("fetching the OpenAPI schema '%s' from the cluster errored with '%v', defaults would be resolved from the bundled schema",
+			schemaPath, err)
+	}
+
+	drift.clusterSchemas[schemaPath] = document
+
+	return document
+}
+
+func (drift *Drift) fetchClusterSchema(schemaPath string) (*openAPIDocument, error) {
+	client := drift.openAPIClient
+	if client == nil {
+		clientSet, err := drift.getKubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		client = clientSet.Discovery().OpenAPIV3()
+	}
+
+	paths, err := client.Paths()
+	if err != nil {
+		return nil, err
+	}
+
+	groupVersion, found := paths[schemaPath]
+	if !found {
+		return nil, &errors.DriftError{Message: fmt.Sprintf("cluster serves no schema for '%s'", schemaPath)}
+	}
+
+	content, err := groupVersion.Schema("application/json")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseOpenAPIDocument(content)
+}
+
+// parseOpenAPIDocument parses the OpenAPI document either in JSON or YAML, and indexes its schemas by the kinds they are of.
+func parseOpenAPIDocument(content []byte) (*openAPIDocument, error) {
+	document := new(openAPIDocument)
+	if err := yaml.Unmarshal(content, document); err != nil {
+		return nil, err
+	}
+
+	document.kinds = make(map[schema.GroupVersionKind]*openAPISchema)
+
+	for _, kindSchema := range document.Components.Schemas {
+		for _, gvk := range kindSchema.Kinds {
+			document.kinds[gvk] = kindSchema
+		}
+	}
+
+	return document, nil
+}
+
+// defaultOf walks the schema of the kind along the path, and returns the default declared on the field at the path.
+func (document *openAPIDocument) defaultOf(gvk schema.GroupVersionKind, fieldPath string) (any, bool) {
+	if document == nil {
+		return nil, false
+	}
+
+	current, found := document.kinds[gvk]
+	if !found {
+		return nil, false
+	}
+
+	for _, segment := range fields.Split(fieldPath) {
+		current = document.resolve(current)
+
+		if strings.HasPrefix(segment, "[") {
+			current = current.Items
+		} else {
+			current = current.Properties[segment]
+		}
+
+		if current == nil {
+			return nil, false
+		}
+	}
+
+	if current.Default != nil {
+		return current.Default, true
+	}
+
+	if resolved := document.resolve(current); resolved.Default != nil {
+		return resolved.Default, true
+	}
+
+	return nil, false
+}
+
+// resolve follows the references of the schema, a reference is either set with $ref or is the only schema under allOf.
+func (document *openAPIDocument) resolve(current *openAPISchema) *openAPISchema {
+	for {
+		switch {
+		case len(current.Ref) != 0:
+			referred, found := document.Components.Schemas[strings.TrimPrefix(current.Ref, openAPISchemaRef)]
+			if !found {
+				return current
+			}
+
+			current = referred
+		case len(current.AllOf) == 1 && len(current.Properties) == 0 && current.Items == nil:
+			current = current.AllOf[0]
+		default:
+			return current
+		}
+	}
+}
+
+// isUnset returns true when the value is not set in the manifest, or is set to an empty value.
+func isUnset(value any) bool {
+	switch typed := value.(type) {
+	case string:
+		return len(typed) == 0
+	default:
+		return isEmpty(value)
+	}
+}
@@ -0,0 +1,216 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/internal/testutil"
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/openapi/openapitest"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+func newDefaultsDrift(t *testing.T, defaultsSchema string, opts ...Option) *Drift {
+	t.Helper()
+
+	drift := New(opts...)
+	drift.SetLogger("error")
+	drift.IgnoreDefaults, drift.DefaultsSchema = true, defaultsSchema
+	require.NoError(t, drift.SetDefaultsSchema())
+
+	return drift
+}
+
+func TestSetDefaultsSchema(t *testing.T) {
+	t.Run("should error on unsupported schemas", func(t *testing.T) {
+		drift := Drift{IgnoreDefaults: true, DefaultsSchema: "remote"}
+		drift.SetLogger("error")
+
+		require.ErrorContains(t, drift.SetDefaultsSchema(), "does not support defaults schema 'remote'")
+	})
+
+	t.Run("should not load the schema when defaults are not ignored", func(t *testing.T) {
+		drift := Drift{DefaultsSchema: "remote"}
+		drift.SetLogger("error")
+
+		require.NoError(t, drift.SetDefaultsSchema())
+		assert.Nil(t, drift.bundledSchema)
+	})
+}
+
+func TestOpenAPIDocumentDefaultOf(t *testing.T) {
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	pod := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+
+	t.Run("should resolve the defaults from the bundled schema", func(t *testing.T) {
+		drift := newDefaultsDrift(t, DefaultsSchemaBundled)
+
+		tests := []struct {
+			gvk      schema.GroupVersionKind
+			path     string
+			expected any
+			found    bool
+		}{
+			{gvk: deployment, path: "spec.revisionHistoryLimit", expected: float64(10), found: true},
+			{gvk: deployment, path: "spec.template.spec.containers[1].imagePullPolicy", expected: "IfNotPresent", found: true},
+			{gvk: deployment, path: "spec.template.spec.containers[0].livenessProbe.httpGet.scheme", expected: "HTTP", found: true},
+			{gvk: pod, path: "spec.dnsPolicy", expected: "ClusterFirst", found: true},
+			{gvk: deployment, path: "spec.replicas", found: false},
+			{gvk: deployment, path: "spec.template.spec.containers[0].image", found: false},
+			{gvk: schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Unknown"}, path: "spec.replicas", found: false},
+		}
+
+		for _, test := range tests {
+			defaultValue, found := drift.bundledSchema.defaultOf(test.gvk, test.path)
+			assert.Equal(t, test.found, found, test.path)
+			assert.Equal(t, test.expected, defaultValue, test.path)
+		}
+	})
+
+	t.Run("should resolve the defaults from the schema of the cluster", func(t *testing.T) {
+		drift := newDefaultsDrift(t, DefaultsSchemaCluster, WithOpenAPIClient(openapitest.NewEmbeddedFileClient()))
+
+		document := drift.clusterSchema(pod.GroupVersion())
+		require.NotNil(t, document)
+
+		defaultValue, found := document.defaultOf(pod, "spec.containers[0].ports[0].protocol")
+		assert.True(t, found)
+		assert.Equal(t, "TCP", defaultValue)
+
+		_, found = document.defaultOf(pod, "spec.dnsPolicy")
+		assert.False(t, found)
+	})
+}
+
+func TestSuppressDefaults(t *testing.T) {
+	newDeviation := func(changes ...*deviation.Change) *deviation.Deviation {
+		return &deviation.Deviation{
+			APIVersion: "apps/v1", Kind: "Deployment", Resource: "sample", HasDrift: true, Deviations: "diff", Changes: changes,
+		}
+	}
+
+	t.Run("should suppress the changes on the fields left out that are set to their defaults", func(t *testing.T) {
+		drift := newDefaultsDrift(t, DefaultsSchemaBundled)
+		containers := "spec.template.spec.containers"
+
+		dvn := newDeviation(
+			&deviation.Change{Path: "spec.revisionHistoryLimit", Type: deviation.ChangeRemoved, Live: int64(10)},
+			&deviation.Change{Path: containers + "[0].imagePullPolicy", Type: deviation.ChangeModified, Live: "IfNotPresent", Desired: ""},
+			&deviation.Change{Path: containers + "[1].imagePullPolicy", Type: deviation.ChangeModified, Live: "IfNotPresent", Desired: "Always"},
+			&deviation.Change{Path: "spec.progressDeadlineSeconds", Type: deviation.ChangeRemoved, Live: int64(300)},
+			&deviation.Change{Path: "spec.replicas", Type: deviation.ChangeModified, Desired: int64(1), Live: int64(2)},
+		)
+
+		drift.suppressDefaultedChanges(dvn, nil)
+
+		assert.True(t, dvn.HasDrift)
+		assert.Equal(t, "diff", dvn.Deviations)
+		assert.Equal(t, []string{
+			"spec.template.spec.containers[1].imagePullPolicy", "spec.progressDeadlineSeconds", "spec.replicas",
+		}, changePaths(dvn.Changes))
+		assert.Equal(t, []string{"spec.revisionHistoryLimit", "spec.template.spec.containers[0].imagePullPolicy"}, changePaths(dvn.Suppressed))
+		assert.Equal(t, suppressedByDefault, dvn.Suppressed[0].SuppressedBy)
+	})
+
+	t.Run("should not suppress the fields left out that are not among the changes", func(t *testing.T) {
+		drift := newDefaultsDrift(t, DefaultsSchemaBundled)
+
+		dvn := newDeviation(&deviation.Change{Path: "spec.replicas", Type: deviation.ChangeModified, Desired: int64(1), Live: int64(2)})
+
+		drift.suppressDefaultedChanges(dvn, []*deviation.Change{
+			{Path: "spec.revisionHistoryLimit", Type: deviation.ChangeRemoved, Live: int64(10)},
+			{Path: "spec.replicas", Type: deviation.ChangeModified, Desired: int64(1), Live: int64(2)},
+		})
+
+		assert.True(t, dvn.HasDrift)
+		assert.Equal(t, []string{"spec.replicas"}, changePaths(dvn.Changes))
+		assert.Empty(t, dvn.Suppressed)
+	})
+
+	t.Run("should not consider the resource as drifted when all the changes are suppressed", func(t *testing.T) {
+		drift := newDefaultsDrift(t, DefaultsSchemaCluster, WithOpenAPIClient(openapitest.NewFakeClient()))
+
+		dvn := newDeviation(&deviation.Change{Path: "spec.template.spec.dnsPolicy", Type: deviation.ChangeRemoved, Live: "ClusterFirst"})
+
+		drift.suppressDefaultedChanges(dvn, nil)
+
+		assert.False(t, dvn.HasDrift)
+		assert.Empty(t, dvn.Deviations)
+		assert.Empty(t, dvn.Changes)
+		assert.Len(t, dvn.Suppressed, 1)
+	})
+
+	t.Run("should leave the changes as is when defaults are not ignored", func(t *testing.T) {
+		drift := New()
+		drift.SetLogger("error")
+
+		dvn := newDeviation(&deviation.Change{Path: "spec.revisionHistoryLimit", Type: deviation.ChangeRemoved, Live: int64(10)})
+
+		require.NoError(t, drift.suppressDefaults(t.Context(), dvn, "sample", new(resourceObjects)))
+
+		assert.True(t, dvn.HasDrift)
+		assert.Len(t, dvn.Changes, 1)
+		assert.Empty(t, dvn.Suppressed)
+	})
+}
+
+func changePaths(changes []*deviation.Change) []string {
+	paths := make([]string, 0, len(changes))
+	for _, change := range changes {
+		paths = append(paths, change.Path)
+	}
+
+	return paths
+}
+
+func TestDiffResourceSuppressesDefaults(t *testing.T) {
+	for _, diffEngine := range []string{DiffEngineKubectl, DiffEngineNative} {
+		t.Run("should suppress the drifted fields left out of the manifest that are set to their defaults with "+diffEngine, func(t *testing.T) {
+			live := newDeployment(2)
+			require.NoError(t, unstructured.SetNestedField(live.Object, int64(10), "spec", "revisionHistoryLimit"))
+			require.NoError(t, unstructured.SetNestedField(live.Object, int64(5), "spec", "minReadySeconds"))
+			require.NoError(t, unstructured.SetNestedField(live.Object, int64(600), "spec", "progressDeadlineSeconds"))
+
+			desired := newDeployment(1)
+			desired.SetManagedFields(nil)
+
+			exec := &testutil.FakeExec{Diff: "-  replicas: 2\n+  replicas: 1\n"}
+
+			drift := newDefaultsDrift(t, DefaultsSchemaBundled, append(newFakeClusterOptions(live), WithCommandExecutor(exec.Executor()))...)
+			drift.DiffEngine = diffEngine
+
+			// fields left out of the manifest are dropped by its dry-run apply when owned by the field manager applying it,
+			// hence they show up as changes.
+			dynamicClient := drift.dynamicClient.(*dynamicFake.FakeDynamicClient)
+			dynamicClient.PrependReactor("patch", "*", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+				_, merged, err := testutil.DryRunApplyReactor(dynamicClient)(action)
+				if err != nil {
+					return true, nil, err
+				}
+
+				unstructured.RemoveNestedField(merged.(*unstructured.Unstructured).Object, "spec", "revisionHistoryLimit")
+				unstructured.RemoveNestedField(merged.(*unstructured.Unstructured).Object, "spec", "minReadySeconds")
+
+				return true, merged, nil
+			})
+
+			dvn, err := drift.diffResource(t.Context(), &deviation.Deviation{
+				APIVersion: "apps/v1", Kind: "Deployment", Resource: "sample", ManifestPath: writeManifest(t, desired.Object),
+			}, "sample")
+			require.NoError(t, err)
+
+			assert.True(t, dvn.HasDrift)
+			assert.Equal(t, []string{"spec.replicas", "spec.minReadySeconds"}, changePaths(dvn.Changes),
+				"fields left out of the manifest that are not set to their defaults should not be suppressed")
+			require.Equal(t, []string{"spec.revisionHistoryLimit"}, changePaths(dvn.Suppressed),
+				"fields left out of the manifest that have not drifted (ex: progressDeadlineSeconds) should not be suppressed")
+			assert.Equal(t, suppressedByDefault, dvn.Suppressed[0].SuppressedBy)
+			assert.InDelta(t, 10, dvn.Suppressed[0].Live, 0)
+		})
+	}
+}
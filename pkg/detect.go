@@ -0,0 +1,80 @@
+package pkg
+
+import (
+	"context"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+)
+
+// Target selects the release/chart, or all the releases, from which the drifts are to be identified.
+type Target struct {
+	// Release is the name of the helm release, it is not required when All is set.
+	Release string
+	// Chart is the path to the chart to be rendered with the values set on Drift.
+	// When left empty, the manifests are fetched from the release instead.
+	Chart string
+	// Namespace of the release, the namespace set with SetNamespace is used when left empty.
+	Namespace string
+	// All selects all the releases from the cluster, or from the namespace when set.
+	All bool
+}
+
+// Detect identifies the drifts from the target and returns them as a report, the drifts are not rendered.
+// Reports could be rendered in the OutputFormat set with Render.
+func (drift *Drift) Detect(ctx context.Context, target Target) (*Report, error) {
+	if !target.All && len(target.Release) == 0 {
+		return nil, &errors.DriftError{Message: "release to identify drifts from cannot be empty, unless all releases are selected"}
+	}
+
+	drift.All = target.All
+	drift.FromRelease = len(target.Chart) == 0
+	drift.SetRelease(target.Release)
+	drift.SetChart(target.Chart)
+
+	if len(target.Namespace) != 0 {
+		drift.SetNamespace(target.Namespace)
+	}
+
+	if err := drift.SetReleasesToSkips(); err != nil {
+		return nil, err
+	}
+
+	if err := drift.SetIgnoreRules(); err != nil {
+		return nil, err
+	}
+
+	if err := drift.SetNormalizers(); err != nil {
+		return nil, err
+	}
+
+	if err := drift.SetDefaultsSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := drift.SetMutators(); err != nil {
+		return nil, err
+	}
+
+	if err := drift.SetBaseline(); err != nil {
+		return nil, err
+	}
+
+	if drift.All {
+		return drift.detectAll(ctx)
+	}
+
+	return drift.detectRelease(ctx)
+}
+
+// Render renders the drifts from the report to the writer set, in the OutputFormat set.
+func (drift *Drift) Render(report *Report) error {
+	if err := drift.SetOutputFormats(); err != nil {
+		return err
+	}
+
+	drift.SetRenderer()
+
+	drift.timeSpent = report.TimeSpent
+
+	return drift.render(report.Releases)
+}
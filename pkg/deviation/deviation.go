@@ -1,6 +1,8 @@
 package deviation
 
 import (
+	"strings"
+
 	"github.com/thoas/go-funk"
 )
 
@@ -12,6 +14,25 @@ const (
 	No      = "NO"
 )
 
+// Types of the Change identified on a field.
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// States of the Deviation, when drifts of the manifest could not be identified as either drifted or not,
+// when the resource is not one of the manifests from the release anymore, when the manifest has no live object in the cluster
+// or, when comparing releases, when the resource is present only in the release compared against.
+// Drifts that are accepted by the baseline are not considered as drifts, and are in the state baselined.
+const (
+	StatusTimedOut  = "timed-out"
+	StatusOrphaned  = "orphaned"
+	StatusMissing   = "missing"
+	StatusExtra     = "extra"
+	StatusBaselined = "baselined"
+)
+
 // DriftedRelease holds drift information of the selected release/chart.
 type DriftedRelease struct {
 	Chart      string       `json:"chart,omitempty" yaml:"chart,omitempty"`
@@ -23,14 +44,36 @@ type DriftedRelease struct {
 
 // Deviation holds drift information of all manifests from the selected release/chart.
 type Deviation struct {
-	HasDrift     bool   `json:"has_drift,omitempty" yaml:"has_drift,omitempty"`
-	NameSpace    string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
-	Deviations   string `json:"deviations,omitempty" yaml:"deviations,omitempty"`
-	Kind         string `json:"kind,omitempty" yaml:"kind,omitempty"`
-	Resource     string `json:"resource,omitempty" yaml:"resource,omitempty"`
-	APIVersion   string `json:"api_version,omitempty" yaml:"api_version,omitempty"`
-	TemplatePath string `json:"template_path,omitempty" yaml:"template_path,omitempty"`
-	ManifestPath string `json:"manifest_path,omitempty" yaml:"manifest_path,omitempty"`
+	HasDrift     bool      `json:"has_drift,omitempty" yaml:"has_drift,omitempty"`
+	NameSpace    string    `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Deviations   string    `json:"deviations,omitempty" yaml:"deviations,omitempty"`
+	Kind         string    `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Resource     string    `json:"resource,omitempty" yaml:"resource,omitempty"`
+	APIVersion   string    `json:"api_version,omitempty" yaml:"api_version,omitempty"`
+	TemplatePath string    `json:"template_path,omitempty" yaml:"template_path,omitempty"`
+	ManifestPath string    `json:"manifest_path,omitempty" yaml:"manifest_path,omitempty"`
+	Changes      []*Change `json:"changes,omitempty" yaml:"changes,omitempty"`
+	Suppressed   []*Change `json:"suppressed,omitempty" yaml:"suppressed,omitempty"`
+	Status       string    `json:"status,omitempty" yaml:"status,omitempty"`
+	// Source is the path of the chart template the manifest is rendered from, as set by helm (ex: sample/templates/deployment.yaml).
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+	// MatchedRevision is the latest of the other revisions of the release whose manifest matches the live state of the drifted resource,
+	// it is set only when the history of the release is looked up and is 0 when none of the revisions match.
+	MatchedRevision int `json:"matched_revision,omitempty" yaml:"matched_revision,omitempty"`
+}
+
+// Change holds the drift identified on a single field of the manifest.
+// Desired is the value from the chart/release and Live is the value found in the cluster.
+// SuppressedBy is set when the change is not considered as drift, and holds the reason for it.
+// Manager is the field manager that last updated the field on the live object, and UpdatedAt is the time of that update.
+type Change struct {
+	Path         string `json:"path,omitempty" yaml:"path,omitempty"`
+	Type         string `json:"type,omitempty" yaml:"type,omitempty"`
+	Desired      any    `json:"desired,omitempty" yaml:"desired,omitempty"`
+	Live         any    `json:"live,omitempty" yaml:"live,omitempty"`
+	SuppressedBy string `json:"suppressed_by,omitempty" yaml:"suppressed_by,omitempty"`
+	Manager      string `json:"manager,omitempty" yaml:"manager,omitempty"`
+	UpdatedAt    string `json:"updated_at,omitempty" yaml:"updated_at,omitempty"`
 }
 
 type (
@@ -81,7 +124,12 @@ func (dvn *DriftedReleases) Drifted() bool {
 }
 
 // Drifted returns Yes if at least one of the manifest from a release has Drifted.
+// The status is returned instead, when the manifest is in one of the other states (ex: timed-out).
 func (dvn *Deviation) Drifted() string {
+	if len(dvn.Status) != 0 {
+		return strings.ToUpper(dvn.Status)
+	}
+
 	if dvn.HasDrift {
 		return Yes
 	}
@@ -126,3 +174,16 @@ func (dvn *Deviations) Count() int {
 
 	return count
 }
+
+// CountByStatus returns total number of manifests in the release that are in the status passed.
+func (dvn *Deviations) CountByStatus(status string) int {
+	var count int
+
+	for _, dft := range *dvn {
+		if dft.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
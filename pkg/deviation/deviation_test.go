@@ -39,6 +39,18 @@ func TestDeviationStatus(t *testing.T) {
 	assert.Equal(t, Failed, driftMap["status"])
 }
 
+func TestTimedOutDeviation(t *testing.T) {
+	deviations := Deviations{
+		{Resource: "clean"},
+		{Resource: "slow", Status: StatusTimedOut},
+	}
+
+	assert.Equal(t, "TIMED-OUT", deviations[1].Drifted())
+	assert.Equal(t, 1, deviations.CountByStatus(StatusTimedOut))
+	assert.Equal(t, Success, deviations.Status())
+	assert.Equal(t, 0, deviations.Count())
+}
+
 func TestSuccessfulStatuses(t *testing.T) {
 	releases := DriftedReleases{{Release: "clean"}}
 	deviations := Deviations{{Resource: "clean"}}
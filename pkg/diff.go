@@ -1,16 +1,18 @@
 package pkg
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
 
-	"github.com/nikhilsbhat/helm-drift/pkg/command"
 	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
-	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+	driftError "github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"github.com/thoas/go-funk"
 )
 
-func (drift *Drift) Diff(renderedManifests *deviation.DriftedRelease) (*deviation.DriftedRelease, error) {
+func (drift *Drift) Diff(ctx context.Context, renderedManifests *deviation.DriftedRelease) (*deviation.DriftedRelease, error) {
 	var (
 		waitGroup sync.WaitGroup
 		errChan   = make(chan error, len(renderedManifests.Deviations))
@@ -26,7 +28,7 @@ func (drift *Drift) Diff(renderedManifests *deviation.DriftedRelease) (*deviatio
 
 	if drift.Limit != 0 {
 		drift.log.Infof(
-			"limit on concurrency is set to '%d', so batching the 'kubectl diff' executions", drift.Limit,
+			"limit on concurrency is set to '%d', so batching the diff executions", drift.Limit,
 		)
 	}
 
@@ -52,53 +54,27 @@ func (drift *Drift) Diff(renderedManifests *deviation.DriftedRelease) (*deviatio
 			defer waitGroup.Done()
 			defer func() { <-sem }()
 
-			manifestPath := dvn.ManifestPath
-
-			drift.log.Debugf("calculating diff for %s", manifestPath)
-
-			arguments := []string{
-				"--show-managed-fields=false",
-				fmt.Sprintf("--concurrency=%d", drift.Concurrency),
-				fmt.Sprintf("-f=%s", manifestPath),
-			}
+			drift.log.Debugf("calculating diff for %s", dvn.ManifestPath)
 
 			nameSpace := drift.setNameSpace(renderedManifests, dvn)
 			drift.log.Debugf("setting namespace to %s", nameSpace)
 
-			isManagedByHPA, err := drift.IsManagedByHPA(dvn.Resource, dvn.Kind, nameSpace)
-			if err != nil {
-				handleError(err)
-
-				return
-			}
-
-			cmd := command.NewCommand("kubectl", drift.log)
+			resourceCtx, cancel := withTimeout(ctx, drift.ResourceTimeout)
+			defer cancel()
 
-			cmd.SetKubeDiffCmd(drift.kubeConfig, drift.kubeContext, nameSpace, arguments...)
-
-			dft, err := cmd.RunKubeDiffCmd(dvn)
+			dft, err := drift.diffResource(resourceCtx, dvn, nameSpace)
 			if err != nil {
-				handleError(err)
-
-				return
-			}
-
-			if !isManagedByHPA {
-				diffs[index] = dft
+				if !errors.Is(resourceCtx.Err(), context.DeadlineExceeded) {
+					handleError(err)
 
-				return
-			}
-
-			hasOnlyChangesScaledByHpa, err := drift.HasOnlyChangesScaledByHpa(dft.Deviations)
-			if err != nil {
-				handleError(err)
+					return
+				}
 
-				return
-			}
+				drift.log.Warnf("identifying drifts of '%s' '%s' timed out, errored with '%v'", dvn.Kind, dvn.Resource, err)
 
-			if !dft.HasDrift || hasOnlyChangesScaledByHpa && drift.IgnoreHPAChanges {
-				dft.HasDrift = false
-				dft.Deviations = ""
+				dft = dvn
+				dft.HasDrift, dft.Deviations, dft.Changes, dft.Suppressed = false, "", nil, nil
+				dft.Status = deviation.StatusTimedOut
 			}
 
 			diffs[index] = dft
@@ -106,7 +82,22 @@ func (drift *Drift) Diff(renderedManifests *deviation.DriftedRelease) (*deviatio
 	}
 
 	if diffErrors := collectErrors(errChan); len(diffErrors) != 0 {
-		return nil, &errors.DriftError{Message: fmt.Sprintf("calculating diff errored with: %s", strings.Join(diffErrors, "\n"))}
+		return nil, &driftError.DriftError{Message: fmt.Sprintf("calculating diff errored with: %s", strings.Join(diffErrors, "\n"))}
+	}
+
+	if drift.DetectOrphans {
+		orphans, err := drift.getOrphans(ctx, renderedManifests)
+		if err != nil {
+			return nil, err
+		}
+
+		diffs = append(diffs, orphans...)
+	}
+
+	if drift.OnlyMissing {
+		diffs = funk.Filter(diffs, func(dvn *deviation.Deviation) bool {
+			return dvn.Status == deviation.StatusMissing
+		}).([]*deviation.Deviation)
 	}
 
 	renderedManifests.Deviations = diffs
@@ -118,6 +109,96 @@ func (drift *Drift) Diff(renderedManifests *deviation.DriftedRelease) (*deviatio
 	return renderedManifests, nil
 }
 
+// diffResource identifies the drifts of a single manifest,
+// drifts on fields ignored, set to their defaults or owned by the mutators enabled (ex: replicas scaled by HPA) are suppressed.
+// The live object of the manifest is fetched from the cluster once, and is shared by all the steps.
+func (drift *Drift) diffResource(ctx context.Context, dvn *deviation.Deviation, nameSpace string) (*deviation.Deviation, error) {
+	objects := new(resourceObjects)
+
+	dft, err := drift.diffManifest(ctx, dvn, nameSpace, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dft.Suppressed) != 0 {
+		if err = drift.setSuppressedChanges(ctx, dft, nameSpace, objects); err != nil {
+			drift.log.Warnf("identifying drifts on ignored fields of '%s' '%s' errored with '%v'", dft.Kind, dft.Resource, err)
+
+			dft.Suppressed = nil
+		}
+	}
+
+	if err = drift.suppressDefaults(ctx, dft, nameSpace, objects); err != nil {
+		return nil, err
+	}
+
+	if err = drift.suppressMutations(ctx, dft, nameSpace); err != nil {
+		return nil, err
+	}
+
+	return dft, nil
+}
+
+// diffManifest identifies the drifts of a single manifest using the diff engine selected.
+func (drift *Drift) diffManifest(
+	ctx context.Context, dvn *deviation.Deviation, nameSpace string, objects *resourceObjects,
+) (*deviation.Deviation, error) {
+	switch drift.DiffEngine {
+	case DiffEngineNative:
+		return drift.nativeDiff(ctx, dvn, nameSpace, objects)
+	case DiffEngineKubectl, "":
+		return drift.kubectlDiff(ctx, dvn, nameSpace, objects)
+	default:
+		return nil, &driftError.DriftError{Message: fmt.Sprintf("helm drift does not support diff engine '%s'", drift.DiffEngine)}
+	}
+}
+
+// kubectlDiff identifies the drifts of the manifest with 'kubectl diff'. The field level changes of the drifted manifest are identified
+// on a best-effort basis, when they could not be (ex: no permissions to dry-run apply) the drifts are reported without them.
+func (drift *Drift) kubectlDiff(
+	ctx context.Context, dvn *deviation.Deviation, nameSpace string, objects *resourceObjects,
+) (*deviation.Deviation, error) {
+	arguments := []string{
+		"--show-managed-fields=false",
+		fmt.Sprintf("--concurrency=%d", drift.Concurrency),
+		fmt.Sprintf("-f=%s", dvn.ManifestPath),
+	}
+
+	cmd := drift.newCommand(ctx, "kubectl")
+
+	cmd.SetKubeDiffCmd(drift.kubeConfig, drift.kubeContext, nameSpace, arguments...)
+
+	dft, err := cmd.RunKubeDiffCmd(dvn)
+	if err != nil || !dft.HasDrift {
+		return dft, err
+	}
+
+	if liveIsEmpty(dft.Deviations) {
+		dft.Status = deviation.StatusMissing
+	}
+
+	if err = drift.setChanges(ctx, dft, nameSpace, objects); err != nil {
+		drift.log.Warnf("identifying field level changes of '%s' '%s' errored with '%v', hence reporting its drifts without them",
+			dft.Kind, dft.Resource, err)
+
+		dft.Changes = nil
+	}
+
+	return dft, nil
+}
+
+// liveIsEmpty returns true when the live side of the diff rendered by 'kubectl diff' is empty,
+// which is when the manifest has no live object in the cluster.
+func liveIsEmpty(diff string) bool {
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "@@ ") {
+			return strings.HasPrefix(line, "@@ -0,0 ")
+		}
+	}
+
+	return false
+}
+
 func collectErrors(errChan <-chan error) []string {
 	var collectedErrors []string
 
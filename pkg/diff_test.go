@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/internal/testutil"
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thoas/go-funk"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+func TestLiveIsEmpty(t *testing.T) {
+	header := "--- /tmp/LIVE-1/apps.v1.Deployment.sample.sample\n+++ /tmp/MERGED-1/apps.v1.Deployment.sample.sample\n"
+
+	tests := []struct {
+		name     string
+		diff     string
+		expected bool
+	}{
+		{
+			name:     "should be empty when the whole object is added",
+			diff:     header + "@@ -0,0 +1,2 @@\n+kind: Deployment\n",
+			expected: true,
+		},
+		{
+			name:     "should not be empty when the object is modified",
+			diff:     header + "@@ -6,7 +6,7 @@\n-  replicas: 2\n",
+			expected: false,
+		},
+		{
+			name:     "should not be empty when the diff has no hunks",
+			diff:     "-  replicas: 2\n+  replicas: 1\n",
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, liveIsEmpty(test.diff))
+		})
+	}
+}
+
+func TestDiffResourceFetchesLiveObjectOnce(t *testing.T) {
+	for _, diffEngine := range []string{DiffEngineKubectl, DiffEngineNative} {
+		t.Run("should share the live object among the steps with "+diffEngine, func(t *testing.T) {
+			live := newDeployment(2)
+			require.NoError(t, unstructured.SetNestedField(live.Object, int64(10), "spec", "revisionHistoryLimit"))
+
+			desired := newDeployment(1)
+			desired.SetManagedFields(nil)
+
+			exec := &testutil.FakeExec{Diff: "-  replicas: 2\n+  replicas: 1\n"}
+
+			drift := newDefaultsDrift(t, DefaultsSchemaBundled, append(newFakeClusterOptions(live), WithCommandExecutor(exec.Executor()))...)
+			drift.DiffEngine = diffEngine
+
+			dvn, err := drift.diffResource(t.Context(), &deviation.Deviation{
+				APIVersion: "apps/v1", Kind: "Deployment", Resource: "sample", ManifestPath: writeManifest(t, desired.Object),
+				Suppressed: []*deviation.Change{{Path: "spec.minReadySeconds", Desired: int64(5), SuppressedBy: suppressedByIgnoreRule}},
+			}, "sample")
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"spec.minReadySeconds"}, changePaths(dvn.Suppressed))
+
+			gets := funk.Filter(drift.dynamicClient.(*dynamicFake.FakeDynamicClient).Actions(), func(action k8sTesting.Action) bool {
+				return action.GetVerb() == "get"
+			}).([]k8sTesting.Action)
+			assert.Len(t, gets, 1, "the live object should be fetched once for all the steps")
+		})
+	}
+}
@@ -16,17 +16,17 @@ const (
 )
 
 func (drift *Drift) renderToDisk(manifests []string, chartName, releaseName, releaseNamespace any) (*deviation.DriftedRelease, error) {
-	manifests = NewHelmTemplates(manifests).FilterByHelmHook(drift)
-	manifests = NewHelmTemplates(manifests).FilterBySkip(drift)
-	manifests = NewHelmTemplates(manifests).FilterByKind(drift)
-	manifests = NewHelmTemplates(manifests).FilterByName(drift)
-
 	releaseDrifted := &deviation.DriftedRelease{
 		Namespace: releaseNamespace.(string),
 		Release:   releaseName.(string),
 		Chart:     chartName.(string),
 	}
 
+	manifests, err := drift.filterManifests(manifests)
+	if err != nil {
+		return releaseDrifted, &errors.DriftError{Message: fmt.Sprintf("filtering manifests of release '%s' errored with '%v'", releaseName, err)}
+	}
+
 	templatePath := filepath.Join(drift.TempPath, drift.release)
 	if drift.All {
 		templatePath = filepath.Join(drift.TempPath, "all", releaseName.(string))
@@ -35,7 +35,7 @@ func (drift *Drift) renderToDisk(manifests []string, chartName, releaseName, rel
 	drift.log.Debugf("rendering helm manifests to disk under %s", templatePath)
 	drift.log.Debugf("creating directories '%s' to generate manifests", templatePath)
 
-	if err := os.MkdirAll(templatePath, templatePathPermission); err != nil {
+	if err = os.MkdirAll(templatePath, templatePathPermission); err != nil {
 		log.Errorf("creating template path '%s' errored with '%v'", templatePath, err)
 
 		return releaseDrifted, err
@@ -55,8 +55,21 @@ func (drift *Drift) renderToDisk(manifests []string, chartName, releaseName, rel
 
 		drift.log.Debugf("generating manifest '%s'", template.Resource)
 
+		manifestToRender, suppressed, err := drift.applyIgnoreRules(manifest, template, releaseName.(string), releaseNamespace.(string))
+		if err != nil {
+			log.Errorf("applying ignore rules on manifest '%s' errored with '%v'", template.Resource, err)
+
+			return nil, err
+		}
+
+		if manifestToRender, err = drift.normalizeManifest(manifestToRender, template); err != nil {
+			log.Errorf("normalizing manifest '%s' errored with '%v'", template.Resource, err)
+
+			return nil, err
+		}
+
 		manifestPath := filepath.Join(templatePath, fmt.Sprintf("%s.%s.%s.yaml", template.Resource, template.Kind, releaseName))
-		if err = os.WriteFile(manifestPath, []byte(manifest), manifestFilePermission); err != nil {
+		if err = os.WriteFile(manifestPath, []byte(manifestToRender), manifestFilePermission); err != nil {
 			log.Errorf("writing manifest '%s' to disk errored with '%v'", manifestPath, err)
 
 			return nil, err
@@ -70,7 +83,9 @@ func (drift *Drift) renderToDisk(manifests []string, chartName, releaseName, rel
 			Resource:     template.Resource,
 			NameSpace:    template.NameSpace,
 			TemplatePath: templatePath,
+			Source:       templateSource(manifest),
 			ManifestPath: manifestPath,
+			Suppressed:   suppressed,
 		}
 
 		templates = append(templates, dvn)
@@ -94,6 +109,24 @@ func (drift *Drift) renderToDisk(manifests []string, chartName, releaseName, rel
 	return releaseDrifted, nil
 }
 
+// filterManifests drops the manifests that are not selected for identifying drifts, by hooks, kinds and names.
+func (drift *Drift) filterManifests(manifests []string) ([]string, error) {
+	manifests, err := NewHelmTemplates(manifests).FilterByHelmHook(drift)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifests, err = NewHelmTemplates(manifests).FilterBySkip(drift); err != nil {
+		return nil, err
+	}
+
+	if manifests, err = NewHelmTemplates(manifests).FilterByKind(drift); err != nil {
+		return nil, err
+	}
+
+	return NewHelmTemplates(manifests).FilterByName(drift)
+}
+
 func (drift *Drift) cleanManifests(force bool) error {
 	templatePath := filepath.Join(drift.TempPath, drift.release)
 	if drift.All {
@@ -15,7 +15,7 @@ func TestRenderToDiskAndCleanManifests(t *testing.T) {
 	drift.SetLogger("error")
 	drift.SetRelease("release")
 
-	rendered, err := drift.renderToDisk([]string{deploymentManifest}, "chart", "release", "sample")
+	rendered, err := drift.renderToDisk([]string{"# Source: chart/templates/deployment.yaml\n" + deploymentManifest}, "chart", "release", "sample")
 	require.NoError(t, err)
 	require.Len(t, rendered.Deviations, 1)
 
@@ -25,6 +25,7 @@ func TestRenderToDiskAndCleanManifests(t *testing.T) {
 	assert.Equal(t, "sample", rendered.Namespace)
 	assert.Equal(t, "chart", rendered.Chart)
 	assert.Equal(t, "Deployment", rendered.Deviations[0].Kind)
+	assert.Equal(t, "chart/templates/deployment.yaml", rendered.Deviations[0].Source)
 
 	require.NoError(t, drift.cleanManifests(false))
 	assert.NoFileExists(t, manifestPath)
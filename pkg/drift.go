@@ -2,6 +2,7 @@ package pkg
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -12,9 +13,16 @@ import (
 
 	"github.com/nikhilsbhat/common/errors"
 	"github.com/nikhilsbhat/common/renderer"
+	"github.com/nikhilsbhat/helm-drift/pkg/command"
 	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	driftError "github.com/nikhilsbhat/helm-drift/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/openapi"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/util/homedir"
 )
 
@@ -22,6 +30,20 @@ const (
 	// TemplateRegex is the default regex, that is used to split one big helm template to multiple templates.
 	// Splitting templates eases the task of  identifying Kubernetes objects.
 	TemplateRegex = `---\n# Source:\s.*.`
+	// DiffEngineKubectl identifies drifts by running 'kubectl diff' for every rendered manifest.
+	DiffEngineKubectl = "kubectl"
+	// DiffEngineNative identifies drifts in-process with server-side apply dry-run, without requiring kubectl.
+	DiffEngineNative = "native"
+	// TableDetailRelease renders a row for every release in the table of the drifts from all the releases.
+	TableDetailRelease = "release"
+	// TableDetailResource renders a row for every resource that is not in sync in the table of the drifts from all the releases.
+	TableDetailResource = "resource"
+	// FailOnAny fails when any of the drifts are identified.
+	FailOnAny = "any"
+	// FailOnMissing fails only when resources are missing from the cluster, the rest of the drifts are reported without failing.
+	FailOnMissing = "missing"
+	// FailOnNone never fails on the drifts identified, same as DisableExitWithError.
+	FailOnNone = "none"
 )
 
 // Drift represents GetDrift.
@@ -39,13 +61,23 @@ type Drift struct {
 	SkipCRDS             bool       `json:"skipCRDS,omitempty"                yaml:"skipCRDS,omitempty"`
 	Validate             bool       `json:"validate,omitempty"                yaml:"validate,omitempty"`
 	IgnoreHPAChanges     bool       `json:"ignore_hpa_changes,omitempty"      yaml:"ignore_hpa_changes,omitempty"`
+	IgnoreVPAChanges     bool       `json:"ignore_vpa_changes,omitempty"      yaml:"ignore_vpa_changes,omitempty"`
+	IgnoreDefaults       bool       `json:"ignore_defaults,omitempty"         yaml:"ignore_defaults,omitempty"`
+	DetectOrphans        bool       `json:"detect_orphans,omitempty"          yaml:"detect_orphans,omitempty"`
+	OnlyMissing          bool       `json:"only_missing,omitempty"            yaml:"only_missing,omitempty"`
+	History              bool       `json:"history,omitempty"                 yaml:"history,omitempty"`
+	DryRun               bool       `json:"dry_run,omitempty"                 yaml:"dry_run,omitempty"`
+	Interactive          bool       `json:"interactive,omitempty"             yaml:"interactive,omitempty"`
 	Revision             int        `json:"revision,omitempty"                yaml:"revision,omitempty"`
 	Concurrency          int        `json:"concurrency,omitempty"             yaml:"concurrency,omitempty"`
 	Limit                int        `json:"limit,omitempty"                   yaml:"limit,omitempty"`
 	Kind                 []string   `json:"kind,omitempty"                    yaml:"kind,omitempty"`
 	SkipReleases         []string   `json:"skip_releases,omitempty"           yaml:"skip_releases,omitempty"`
 	SkipKinds            []string   `json:"skip_kinds,omitempty"              yaml:"skip_kinds,omitempty"`
+	FixKinds             []string   `json:"fix_kinds,omitempty"               yaml:"fix_kinds,omitempty"`
 	IgnoreHookTypes      []string   `json:"ignore_hook_types,omitempty"       yaml:"ignore_hook_types,omitempty"`
+	Normalize            []string   `json:"normalize,omitempty"               yaml:"normalize,omitempty"`
+	IgnoreMutators       []string   `json:"ignore_mutators,omitempty"         yaml:"ignore_mutators,omitempty"`
 	Values               []string   `json:"values,omitempty"                  yaml:"values,omitempty"`
 	StringValues         []string   `json:"string_values,omitempty"           yaml:"string_values,omitempty"`
 	FileValues           []string   `json:"file_values,omitempty"             yaml:"file_values,omitempty"`
@@ -56,25 +88,66 @@ type Drift struct {
 	CustomDiff           string     `json:"custom_diff,omitempty"             yaml:"custom_diff,omitempty"`
 	Name                 string     `json:"name,omitempty"                    yaml:"name,omitempty"`
 	OutputFormat         string     `json:"output_format,omitempty"           yaml:"output_format,omitempty"`
+	DiffEngine           string     `json:"diff_engine,omitempty"             yaml:"diff_engine,omitempty"`
+	TableDetail          string     `json:"table_detail,omitempty"            yaml:"table_detail,omitempty"`
+	FailOn               string     `json:"fail_on,omitempty"                 yaml:"fail_on,omitempty"`
+	DefaultsSchema       string     `json:"defaults_schema,omitempty"         yaml:"defaults_schema,omitempty"`
+	IgnoreFile           string     `json:"ignore_file,omitempty"             yaml:"ignore_file,omitempty"`
+	Baseline             string     `json:"baseline,omitempty"                yaml:"baseline,omitempty"`
+	Timeout              Duration   `json:"timeout,omitempty"                 yaml:"timeout,omitempty"`
+	ResourceTimeout      Duration   `json:"resource_timeout,omitempty"        yaml:"resource_timeout,omitempty"`
 	releasesToSkip       []resourcesInfo
+	ignoreRules          []*IgnoreRule
+	normalizers          []Normalizer
+	customNormalizers    []Normalizer
+	mutators             []*Mutator
+	customMutators       []*Mutator
+	baseline             map[string]string
 	json                 bool
 	yaml                 bool
 	csv                  bool
 	table                bool
+	junit                bool
+	sarif                bool
+	html                 bool
+	markdown             bool
 	release              string
 	chart                string
 	namespace            string
 	kubeConfig           string
 	kubeContext          string
+	against              string
 	timeSpent            float64
 	log                  *logrus.Logger
+	output               io.Writer
+	input                io.Reader
 	writer               *bufio.Writer
 	renderer             renderer.Config
+	commandExecutor      command.Executor
 	kubeClient           kubernetes.Interface
 	kubeClientErr        error
 	kubeClientOnce       sync.Once
-	hpaCache             map[string]map[string]struct{}
-	hpaCacheMu           sync.RWMutex
+	dynamicClient        dynamic.Interface
+	dynamicClientErr     error
+	dynamicClientOnce    sync.Once
+	metadataClient       metadata.Interface
+	metadataClientErr    error
+	metadataClientOnce   sync.Once
+	restMapper           meta.RESTMapper
+	restMapperErr        error
+	restMapperOnce       sync.Once
+	restConfig           *rest.Config
+	restConfigErr        error
+	restConfigOnce       sync.Once
+	targetsCache         map[string]map[string]struct{}
+	targetsCacheMu       sync.RWMutex
+	openAPIClient        openapi.Client
+	bundledSchema        *openAPIDocument
+	clusterSchemas       map[string]*openAPIDocument
+	defaultsMu           sync.Mutex
+	ownedObjects         map[string]*ownedObjectsEntry
+	listableResources    *listableResourcesEntry
+	ownedObjectsMu       sync.Mutex
 }
 
 type resourcesInfo struct {
@@ -94,61 +167,102 @@ func (drift *Drift) SetChart(chart string) {
 
 // SetWriter sets writer to be used by helm drift.
 func (drift *Drift) SetWriter(writer io.Writer) {
+	drift.output = writer
 	drift.writer = bufio.NewWriter(writer)
 }
 
+// SetReader sets reader from which the confirmations are read, when fixing drifts interactively.
+func (drift *Drift) SetReader(reader io.Reader) {
+	drift.input = reader
+}
+
 // SetRenderer sets renderer to Images.
 func (drift *Drift) SetRenderer() {
-	render := renderer.GetRenderer(os.Stdout, drift.log, drift.NoColor, drift.yaml, drift.json, drift.csv, drift.table)
+	output := drift.output
+	if output == nil {
+		output = os.Stdout
+	}
+
+	render := renderer.GetRenderer(output, drift.log, drift.NoColor, drift.yaml, drift.json, drift.csv, drift.table)
 	drift.renderer = render
 }
 
-// GetDrift gets all the drifts that the given release/chart has.
-func (drift *Drift) GetDrift() {
-	startTime := time.Now()
-
-	if err := drift.cleanManifests(true); err != nil {
-		drift.log.Fatalf("cleaning old rendered files failed with: %v", err)
+// GetDrift gets all the drifts that the given release/chart has, the drifts identified are rendered and returned as a report.
+// Commands run to identify the drifts are cancelled, once the context is done.
+func (drift *Drift) GetDrift(ctx context.Context) (*Report, error) {
+	report, err := drift.detectRelease(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	drift.log.Debugf("got all required values to identify drifts from chart/release '%s' proceeding furter to fetch the same", drift.release)
+	if len(report.Releases[0].Deviations) == 0 {
+		drift.log.Info("no drifts were identified")
 
-	if err := drift.setExternalDiff(); err != nil {
-		drift.log.Fatalf("%v", err)
+		return report, nil
 	}
 
-	chart, err := drift.getChartManifests()
-	if err != nil {
-		drift.log.Fatalf("%v", err)
+	if err = drift.render(report.Releases); err != nil {
+		return nil, err
 	}
 
-	kubeKindTemplates := drift.getTemplates(chart)
+	return report, nil
+}
 
-	renderedManifests, err := drift.renderToDisk(kubeKindTemplates, drift.chart, drift.release, drift.namespace)
-	if err != nil {
-		drift.log.Fatalf("%v", err)
+// detectRelease identifies the drifts of the release/chart set, from the manifests rendered on to disk.
+func (drift *Drift) detectRelease(ctx context.Context) (report *Report, err error) {
+	startTime := time.Now()
+
+	ctx, cancel := withTimeout(ctx, drift.Timeout)
+	defer cancel()
+
+	if err = drift.cleanManifests(true); err != nil {
+		return nil, &driftError.DriftError{Message: fmt.Sprintf("cleaning old rendered files failed with: %v", err)}
+	}
+
+	drift.log.Debugf("got all required values to identify drifts from chart/release '%s' proceeding furter to fetch the same", drift.release)
+
+	if err = drift.setExternalDiff(); err != nil {
+		return nil, err
 	}
 
 	defer func(drift *Drift) {
-		if err = drift.cleanManifests(false); err != nil {
-			drift.log.Fatalf("cleaning rendered files failed with: %v", err)
+		if cleanErr := drift.cleanManifests(false); cleanErr != nil && err == nil {
+			err = &driftError.DriftError{Message: fmt.Sprintf("cleaning rendered files failed with: %v", cleanErr)}
 		}
 	}(drift)
 
-	out, err := drift.Diff(renderedManifests)
+	out, err := drift.diffRelease(ctx)
 	if err != nil {
-		drift.log.Fatalf("%v", err)
+		return nil, err
 	}
 
-	if len(out.Deviations) == 0 {
-		drift.log.Info("no drifts were identified")
-	} else {
-		drift.timeSpent = time.Since(startTime).Seconds()
+	if drift.History {
+		drift.setMatchedRevisions(ctx, out, drift.currentRevision())
+	}
 
-		if err = drift.render([]*deviation.DriftedRelease{out}); err != nil {
-			drift.log.Fatalf("%v", err)
-		}
+	drift.applyBaseline(out)
+
+	drift.timeSpent = time.Since(startTime).Seconds()
+
+	return &Report{Releases: []*deviation.DriftedRelease{out}, TimeSpent: drift.timeSpent}, nil
+}
+
+// diffRelease renders the manifests of the release/chart set on to disk and identifies their drifts.
+// The manifests rendered are left on disk, cleaning them is left to the caller.
+func (drift *Drift) diffRelease(ctx context.Context) (*deviation.DriftedRelease, error) {
+	chart, err := drift.getChartManifests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	kubeKindTemplates := drift.getTemplates(chart)
+
+	renderedManifests, err := drift.renderToDisk(kubeKindTemplates, drift.chart, drift.release, drift.namespace)
+	if err != nil {
+		return nil, err
 	}
+
+	return drift.Diff(ctx, renderedManifests)
 }
 
 func (drift *Drift) SetNamespace(namespace string) {
@@ -193,17 +307,23 @@ func (drift *Drift) setExternalDiff() error {
 		return nil
 	}
 
+	if drift.DiffEngine == DiffEngineNative {
+		drift.log.Warnf("custom diff '%s' is not used by the native diff engine, it works only with diff engine '%s'", drift.CustomDiff, DiffEngineKubectl)
+
+		return nil
+	}
+
 	return os.Setenv("KUBECTL_EXTERNAL_DIFF", drift.CustomDiff)
 }
 
-func (drift *Drift) getChartManifests() ([]byte, error) {
+func (drift *Drift) getChartManifests(ctx context.Context) ([]byte, error) {
 	if drift.FromRelease {
 		drift.log.Debugf("from-release is selected, hence fetching manifests for '%s' from helm release", drift.release)
 
-		return drift.getChartFromRelease()
+		return drift.getChartFromRelease(ctx)
 	}
 
 	drift.log.Debugf("fetching manifests for '%s' by rendering helm template locally", drift.release)
 
-	return drift.getChartFromTemplate()
+	return drift.getChartFromTemplate(ctx)
 }
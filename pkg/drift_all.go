@@ -1,6 +1,7 @@
 package pkg
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
@@ -11,32 +12,74 @@ import (
 	helmRelease "helm.sh/helm/v3/pkg/release"
 )
 
-func (drift *Drift) GetAllDrift() {
+// GetAllDrift gets the drifts of all the releases from the cluster, the drifts identified are rendered and returned as a report.
+// Commands run to identify the drifts are cancelled, once the context is done.
+func (drift *Drift) GetAllDrift(ctx context.Context) (*Report, error) {
+	report, err := drift.detectAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = drift.render(report.Releases); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// detectAll identifies the drifts of all the releases from the cluster, it errors if drifts of any of the releases could not be identified.
+func (drift *Drift) detectAll(ctx context.Context) (report *Report, err error) {
 	startTime := time.Now()
 
-	if err := drift.cleanManifests(true); err != nil {
-		drift.log.Fatalf("cleaning old rendered files failed with: %v", err)
+	ctx, cancel := withTimeout(ctx, drift.Timeout)
+	defer cancel()
+
+	if err = drift.cleanManifests(true); err != nil {
+		return nil, &errors.DriftError{Message: fmt.Sprintf("cleaning old rendered files failed with: %v", err)}
 	}
 
 	drift.log.Debugf("got all required values to identify drifts from chart/release '%s' proceeding furter to fetch the same", drift.release)
 
-	if err := drift.setExternalDiff(); err != nil {
-		drift.log.Fatalf("%v", err)
+	if err = drift.setExternalDiff(); err != nil {
+		return nil, err
+	}
+
+	defer func(drift *Drift) {
+		if cleanErr := drift.cleanManifests(false); cleanErr != nil && err == nil {
+			err = &errors.DriftError{Message: fmt.Sprintf("cleaning rendered files failed with: %v", cleanErr)}
+		}
+	}(drift)
+
+	driftedReleases, _, driftErrors, err := drift.identifyAllDrifts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(driftErrors) != 0 {
+		return nil, &errors.DriftError{Message: fmt.Sprintf("identifying drifts errored with: %s", strings.Join(driftErrors, "\n"))}
 	}
 
+	for _, driftedRelease := range driftedReleases {
+		drift.applyBaseline(driftedRelease)
+	}
+
+	drift.timeSpent = time.Since(startTime).Seconds()
+
+	return &Report{Releases: driftedReleases, TimeSpent: drift.timeSpent}, nil
+}
+
+// identifyAllDrifts identifies drifts from all the releases selected, the releases are diffed independently of each other.
+// Releases whose drifts could not be identified are left out of the releases returned and their errors are returned instead,
+// so that a failure on a single release does not fail the drift identification of the rest.
+// The number of releases selected is returned as well, as releases without drifts are left out too.
+func (drift *Drift) identifyAllDrifts(ctx context.Context) ([]*deviation.DriftedRelease, int, []string, error) {
 	releases, err := drift.getChartsFromReleases()
 	if err != nil {
-		drift.log.Fatalf("%v", err)
+		return nil, 0, nil, err
 	}
 
 	releases = resourcesToSkip(drift.releasesToSkip).filterRelease(releases)
 
-	defer func(drift *Drift) {
-		if err = drift.cleanManifests(false); err != nil {
-			drift.log.Fatalf("cleaning rendered files failed with: %v", err)
-		}
-	}(drift)
-
 	driftedReleases := make([]*deviation.DriftedRelease, len(releases))
 
 	sem := make(chan struct{}, drift.releaseConcurrencyLimit(releases))
@@ -47,7 +90,7 @@ func (drift *Drift) GetAllDrift() {
 
 	var waitGroup sync.WaitGroup
 
-	errChan := make(chan error)
+	errChan := make(chan error, len(releases))
 
 	waitGroup.Add(len(releases))
 
@@ -69,19 +112,23 @@ func (drift *Drift) GetAllDrift() {
 
 			deviations, err := drift.renderToDisk(kubeKindTemplates, "", release.Name, release.Namespace)
 			if err != nil {
-				errChan <- err
+				errChan <- &errors.DriftError{Message: fmt.Sprintf("release '%s' from namespace '%s': %v", release.Name, release.Namespace, err)}
 
 				return
 			}
 
-			out, err := drift.Diff(deviations)
+			out, err := drift.Diff(ctx, deviations)
 			if err != nil {
-				errChan <- err
+				errChan <- &errors.DriftError{Message: fmt.Sprintf("release '%s' from namespace '%s': %v", release.Name, release.Namespace, err)}
 
 				return
 			}
 
-			if len(out.Deviations) == 0 && err == nil {
+			if drift.History {
+				drift.setMatchedRevisions(ctx, out, release.Version)
+			}
+
+			if len(out.Deviations) == 0 {
 				drift.log.Infof("no drifts identified for relase '%s'", release.Name)
 
 				return
@@ -91,15 +138,9 @@ func (drift *Drift) GetAllDrift() {
 		}(index, release)
 	}
 
-	if driftErrors := collectErrors(errChan); len(driftErrors) != 0 {
-		drift.log.Fatalf("%v", &errors.DriftError{Message: fmt.Sprintf("identifying drifts errored with: %s", strings.Join(driftErrors, "\n"))})
-	}
-
-	drift.timeSpent = time.Since(startTime).Seconds()
+	driftErrors := collectErrors(errChan)
 
-	if err = drift.render(filterDriftedReleases(driftedReleases)); err != nil {
-		drift.log.Fatalf("%v", err)
-	}
+	return filterDriftedReleases(driftedReleases), len(releases), driftErrors, nil
 }
 
 func (drift *Drift) releaseConcurrencyLimit(releases []*helmRelease.Release) int {
@@ -22,16 +22,19 @@ func TestDriftSettersAndOutputFormats(t *testing.T) {
 	assert.Contains(t, drift.kubeConfig, ".kube/config")
 
 	drift.OutputFormat = "json"
-	drift.SetOutputFormats()
+	require.NoError(t, drift.SetOutputFormats())
 	assert.True(t, drift.json)
 
 	drift = Drift{OutputFormat: "yaml"}
-	drift.SetOutputFormats()
+	require.NoError(t, drift.SetOutputFormats())
 	assert.True(t, drift.yaml)
 
 	drift = Drift{OutputFormat: "table"}
-	drift.SetOutputFormats()
+	require.NoError(t, drift.SetOutputFormats())
 	assert.True(t, drift.table)
+
+	drift = Drift{OutputFormat: "xml"}
+	assert.Error(t, drift.SetOutputFormats())
 }
 
 func TestSetReleasesToSkips(t *testing.T) {
@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+)
+
+// Duration is time.Duration that could be set both from the flags and from the config file in its string form, ex: '30s', '5m'.
+type Duration time.Duration
+
+// String returns the string form of the Duration, it is required to implement pflag.Value.
+func (duration *Duration) String() string {
+	return time.Duration(*duration).String()
+}
+
+// Set parses the value to Duration, it is required to implement pflag.Value.
+func (duration *Duration) Set(value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return &errors.DriftError{Message: fmt.Sprintf("parsing duration '%s' errored with '%v'", value, err)}
+	}
+
+	*duration = Duration(parsed)
+
+	return nil
+}
+
+// Type returns the type of the flag, it is required to implement pflag.Value.
+func (duration *Duration) Type() string {
+	return "duration"
+}
+
+// MarshalJSON renders the Duration in its string form.
+func (duration Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(duration).String())
+}
+
+// UnmarshalJSON parses the Duration either from its string form or from the number of nanoseconds.
+func (duration *Duration) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch typed := value.(type) {
+	case string:
+		return duration.Set(typed)
+	case float64:
+		*duration = Duration(time.Duration(typed))
+
+		return nil
+	default:
+		return &errors.DriftError{Message: fmt.Sprintf("invalid duration '%s'", string(data))}
+	}
+}
+
+// withTimeout returns the context that is done once the timeout elapses, the context is only cancellable when the timeout is not set.
+func withTimeout(ctx context.Context, timeout Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, time.Duration(timeout))
+}
@@ -0,0 +1,37 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestDuration(t *testing.T) {
+	t.Run("should be set from flags", func(t *testing.T) {
+		var duration Duration
+
+		require.NoError(t, duration.Set("1m30s"))
+		assert.Equal(t, Duration(90*time.Second), duration)
+		assert.Equal(t, "1m30s", duration.String())
+		assert.Equal(t, "duration", duration.Type())
+
+		require.Error(t, duration.Set("ninety seconds"))
+	})
+
+	t.Run("should be set from config file", func(t *testing.T) {
+		drift := Drift{}
+
+		require.NoError(t, yaml.UnmarshalStrict([]byte("timeout: 5m\nresource_timeout: 1000000000\n"), &drift))
+		assert.Equal(t, Duration(5*time.Minute), drift.Timeout)
+		assert.Equal(t, Duration(time.Second), drift.ResourceTimeout)
+
+		out, err := yaml.Marshal(Drift{Timeout: Duration(time.Minute)})
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "timeout: 1m0s")
+
+		require.Error(t, yaml.UnmarshalStrict([]byte("timeout: true\n"), &drift))
+	})
+}
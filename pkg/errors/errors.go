@@ -2,14 +2,28 @@ package errors
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"strings"
 
 	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
 	"github.com/thoas/go-funk"
 )
 
+// Codes helm drift exits with, so that CI could tell the drifts identified apart from the errors in identifying them.
+const (
+	// ExitCodeNoDrift is when no drifts were identified, or none of them are the ones set to fail on.
+	ExitCodeNoDrift = 0
+	// ExitCodeDrift is when drifts were identified.
+	ExitCodeDrift = 2
+	// ExitCodeMissing is when drifts were identified, with resources missing from the cluster.
+	ExitCodeMissing = 3
+	// ExitCodeError is when identifying the drifts errored (ex: kubectl or helm failed).
+	ExitCodeError = 4
+	// ExitCodePreValidation is when the prerequisites to identify the drifts are not met.
+	ExitCodePreValidation = 5
+)
+
 type PreValidationError struct {
 	Message string
 }
@@ -23,6 +37,11 @@ type NotAllError struct {
 	Manifests              []*deviation.Deviation
 }
 
+// DriftsFoundError is returned when drifts were identified, so that helm drift exits with ExitCodeDrift or ExitCodeMissing.
+type DriftsFoundError struct {
+	Missing bool
+}
+
 type DiskError struct {
 	Errors chan error
 }
@@ -40,6 +59,14 @@ func (e *DriftError) Error() string {
 	return e.Message
 }
 
+func (e *DriftsFoundError) Error() string {
+	if e.Missing {
+		return "drifts were identified, with resources missing from the cluster"
+	}
+
+	return "drifts were identified"
+}
+
 func (e *NotFoundError) Error() string {
 	return fmt.Sprintf("failed to get key '%s' from the manifest '%s'", e.Key, e.Manifest)
 }
@@ -77,8 +104,32 @@ func (e *NotAllError) Error() string {
 
 	diffJSON, err := json.MarshalIndent(diffs, " ", " ")
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Sprintf("not all manifests were rendered to disk successfully, manifests failed to render: \n%v", diffs)
 	}
 
 	return fmt.Sprintf("not all manifests were rendered to disk successfully, manifests failed to render: \n%v", string(diffJSON))
 }
+
+// ExitCode returns the code helm drift should exit with for the error, it is ExitCodeNoDrift when there is no error.
+func ExitCode(err error) int {
+	var (
+		driftsFound   *DriftsFoundError
+		preValidation *PreValidationError
+	)
+
+	switch {
+	case err == nil:
+		return ExitCodeNoDrift
+	case errors.As(err, &driftsFound):
+		if driftsFound.Missing {
+			return ExitCodeMissing
+		}
+
+		return ExitCodeDrift
+	case errors.As(err, &preValidation):
+		return ExitCodePreValidation
+	default:
+		// DriftError, NotAllError and the errors from kubectl/helm are the errors in identifying the drifts.
+		return ExitCodeError
+	}
+}
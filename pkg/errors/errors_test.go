@@ -2,6 +2,7 @@ package errors_test
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
@@ -55,3 +56,30 @@ func TestNotAllError(t *testing.T) {
 	assert.Contains(t, err, "missing")
 	assert.NotContains(t, err, `"resource": "rendered"`)
 }
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "should exit with 0 when there is no error", err: nil, expected: pkgErr.ExitCodeNoDrift},
+		{name: "should exit with 2 when drifts were identified", err: &pkgErr.DriftsFoundError{}, expected: pkgErr.ExitCodeDrift},
+		{name: "should exit with 3 when resources are missing", err: &pkgErr.DriftsFoundError{Missing: true}, expected: pkgErr.ExitCodeMissing},
+		{name: "should exit with 4 when identifying drifts errored", err: &pkgErr.DriftError{Message: "kubectl failed"}, expected: pkgErr.ExitCodeError},
+		{name: "should exit with 4 when manifests were not rendered", err: &pkgErr.NotAllError{}, expected: pkgErr.ExitCodeError},
+		{name: "should exit with 4 on errors of other types", err: errors.New("unknown"), expected: pkgErr.ExitCodeError},
+		{name: "should exit with 5 when prerequisites are not met", err: &pkgErr.PreValidationError{Message: "pre"}, expected: pkgErr.ExitCodePreValidation},
+		{
+			name:     "should exit with the code of the wrapped error",
+			err:      fmt.Errorf("running drift: %w", &pkgErr.DriftsFoundError{Missing: true}),
+			expected: pkgErr.ExitCodeMissing,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, pkgErr.ExitCode(test.err))
+		})
+	}
+}
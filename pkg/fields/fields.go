@@ -0,0 +1,327 @@
+package fields
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+)
+
+const (
+	separator = "."
+	escape    = "\\"
+)
+
+// Join returns the path of the key under the parent path, dots in the key are escaped so that the path could be split back.
+// ex: Join("metadata.annotations", "helm.sh/hook") returns 'metadata.annotations.helm\.sh/hook'.
+func Join(parent, key string) string {
+	key = strings.ReplaceAll(key, separator, escape+separator)
+	if len(parent) == 0 {
+		return key
+	}
+
+	return parent + separator + key
+}
+
+// Index returns the path of the list item at the index under the parent path, ex: 'spec.containers[0]'.
+func Index(parent string, index int) string {
+	return fmt.Sprintf("%s[%d]", parent, index)
+}
+
+// Split splits the path into the keys and list indexes it is made of.
+// ex: 'spec.containers[0].image' is split into 'spec', 'containers', '[0]', 'image'.
+func Split(path string) []string {
+	segments := make([]string, 0)
+
+	var segment strings.Builder
+
+	flush := func() {
+		if segment.Len() != 0 {
+			segments = append(segments, segment.String())
+			segment.Reset()
+		}
+	}
+
+	for index := 0; index < len(path); index++ {
+		switch char := path[index]; {
+		case char == '\\' && index+1 < len(path) && path[index+1] == '.':
+			segment.WriteByte('.')
+			index++
+		case char == '.':
+			flush()
+		case char == '[':
+			closing := strings.IndexByte(path[index:], ']')
+			if closing == -1 {
+				segment.WriteString(path[index:])
+				index = len(path)
+
+				continue
+			}
+
+			flush()
+			segments = append(segments, path[index:index+closing+1])
+			index += closing
+		default:
+			segment.WriteByte(char)
+		}
+	}
+
+	flush()
+
+	return segments
+}
+
+// Compare identifies the changes on every field between the desired and the live state of an object.
+// When desiredOnly is set, fields that are present only on the live object are not reported,
+// this is useful when the desired state is a manifest rather than an object returned by the API server.
+func Compare(desired, live map[string]any, desiredOnly bool) []*deviation.Change {
+	changes := make([]*deviation.Change, 0)
+
+	compare("", normalize(desired), normalize(live), desiredOnly, &changes)
+
+	return changes
+}
+
+func compare(path string, desired, live any, desiredOnly bool, changes *[]*deviation.Change) {
+	switch desiredValue := desired.(type) {
+	case map[string]any:
+		liveValue, isMap := live.(map[string]any)
+		if !isMap {
+			break
+		}
+
+		for _, key := range sortedKeys(desiredValue) {
+			if liveFieldValue, found := liveValue[key]; found {
+				compare(Join(path, key), desiredValue[key], liveFieldValue, desiredOnly, changes)
+
+				continue
+			}
+
+			if desiredValue[key] != nil {
+				*changes = append(*changes, &deviation.Change{Path: Join(path, key), Type: deviation.ChangeAdded, Desired: desiredValue[key]})
+			}
+		}
+
+		if desiredOnly {
+			return
+		}
+
+		for _, key := range sortedKeys(liveValue) {
+			if _, found := desiredValue[key]; !found && liveValue[key] != nil {
+				*changes = append(*changes, &deviation.Change{Path: Join(path, key), Type: deviation.ChangeRemoved, Live: liveValue[key]})
+			}
+		}
+
+		return
+	case []any:
+		liveValue, isList := live.([]any)
+		if !isList {
+			break
+		}
+
+		for index, item := range desiredValue {
+			if index < len(liveValue) {
+				compare(Index(path, index), item, liveValue[index], desiredOnly, changes)
+
+				continue
+			}
+
+			*changes = append(*changes, &deviation.Change{Path: Index(path, index), Type: deviation.ChangeAdded, Desired: item})
+		}
+
+		if desiredOnly {
+			return
+		}
+
+		for index := len(desiredValue); index < len(liveValue); index++ {
+			*changes = append(*changes, &deviation.Change{Path: Index(path, index), Type: deviation.ChangeRemoved, Live: liveValue[index]})
+		}
+
+		return
+	}
+
+	if !reflect.DeepEqual(desired, live) {
+		*changes = append(*changes, &deviation.Change{Path: path, Type: deviation.ChangeModified, Desired: desired, Live: live})
+	}
+}
+
+// normalize converts the object to its JSON equivalent, so that values parsed from YAML manifests and
+// the ones returned by the API server could be compared (ex: int64 and float64 numbers).
+func normalize(object map[string]any) any {
+	if object == nil {
+		return nil
+	}
+
+	out, err := json.Marshal(object)
+	if err != nil {
+		return object
+	}
+
+	var normalized any
+	if err = json.Unmarshal(out, &normalized); err != nil {
+		return object
+	}
+
+	return normalized
+}
+
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Get returns the value of the field at the path from the object.
+func Get(object map[string]any, path string) (any, bool) {
+	matches := find(object, Split(path), "")
+	if len(matches) == 0 {
+		return nil, false
+	}
+
+	return matches[0].value, true
+}
+
+// Remove removes the fields matching the path from the object and returns the values removed, by the path of each field.
+// Items of a list could be matched with '[*]', ex: 'spec.containers[*].image'.
+// Keys having dots could either be escaped or be left as is, ex: 'data.ca\.crt' and 'data.ca.crt' are equivalent.
+func Remove(object map[string]any, path string) map[string]any {
+	removed := make(map[string]any)
+
+	for _, match := range find(object, Split(path), "") {
+		parent, isMap := match.parent.(map[string]any)
+		if !isMap {
+			continue
+		}
+
+		delete(parent, match.key)
+		removed[match.path] = match.value
+	}
+
+	return removed
+}
+
+// Under reports whether the field at the path is the one matching the pattern, or is a field under it.
+// Items of a list could be matched with '[*]' and dots in the keys of the pattern are to be escaped,
+// ex: 'spec.template.spec.containers[0].resources.requests.cpu' is under 'spec.template.spec.containers[*].resources'.
+func Under(path, pattern string) bool {
+	segments, patternSegments := Split(path), Split(pattern)
+	if len(segments) < len(patternSegments) {
+		return false
+	}
+
+	for index, patternSegment := range patternSegments {
+		if patternSegment == "[*]" {
+			if _, isIndex := parseIndex(segments[index]); isIndex {
+				continue
+			}
+
+			return false
+		}
+
+		if patternSegment != segments[index] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Equal reports whether both the values are same once converted to their JSON equivalent.
+func Equal(value1, value2 any) bool {
+	return reflect.DeepEqual(
+		normalize(map[string]any{"value": value1}),
+		normalize(map[string]any{"value": value2}),
+	)
+}
+
+type match struct {
+	path   string
+	parent any
+	key    string
+	value  any
+}
+
+func find(node any, segments []string, path string) []match {
+	if len(segments) == 0 {
+		return nil
+	}
+
+	if index, isIndex := parseIndex(segments[0]); isIndex {
+		list, isList := node.([]any)
+		if !isList {
+			return nil
+		}
+
+		matches := make([]match, 0)
+
+		for itemIndex, item := range list {
+			if index != -1 && index != itemIndex {
+				continue
+			}
+
+			itemPath := Index(path, itemIndex)
+			if len(segments) == 1 {
+				matches = append(matches, match{path: itemPath, parent: list, value: item})
+
+				continue
+			}
+
+			matches = append(matches, find(item, segments[1:], itemPath)...)
+		}
+
+		return matches
+	}
+
+	object, isMap := node.(map[string]any)
+	if !isMap {
+		return nil
+	}
+
+	// keys having dots which are not escaped would be split in to multiple segments,
+	// so joining the segments until a key is found in the object.
+	for count := 1; count <= len(segments); count++ {
+		key := strings.Join(segments[:count], separator)
+
+		value, found := object[key]
+		if !found {
+			continue
+		}
+
+		if count == len(segments) {
+			return []match{{path: Join(path, key), parent: object, key: key, value: value}}
+		}
+
+		if matches := find(value, segments[count:], Join(path, key)); len(matches) != 0 {
+			return matches
+		}
+	}
+
+	return nil
+}
+
+func parseIndex(segment string) (int, bool) {
+	if !strings.HasPrefix(segment, "[") || !strings.HasSuffix(segment, "]") {
+		return 0, false
+	}
+
+	value := strings.TrimSuffix(strings.TrimPrefix(segment, "["), "]")
+	if value == "*" {
+		return -1, true
+	}
+
+	index, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return index, true
+}
@@ -0,0 +1,127 @@
+package fields_test
+
+import (
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/fields"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinAndSplit(t *testing.T) {
+	path := fields.Join(fields.Join("metadata", "annotations"), "kubectl.kubernetes.io/restartedAt")
+	assert.Equal(t, `metadata.annotations.kubectl\.kubernetes\.io/restartedAt`, path)
+	assert.Equal(t, []string{"metadata", "annotations", "kubectl.kubernetes.io/restartedAt"}, fields.Split(path))
+
+	path = fields.Join(fields.Index(fields.Join("spec", "containers"), 0), "image")
+	assert.Equal(t, "spec.containers[0].image", path)
+	assert.Equal(t, []string{"spec", "containers", "[0]", "image"}, fields.Split(path))
+}
+
+func TestCompare(t *testing.T) {
+	desired := map[string]any{
+		"metadata": map[string]any{"name": "sample"},
+		"spec": map[string]any{
+			"replicas": 1,
+			"containers": []any{
+				map[string]any{"name": "app", "image": "nginx:1.25"},
+				map[string]any{"name": "sidecar", "image": "busybox"},
+			},
+			"paused": true,
+		},
+	}
+	live := map[string]any{
+		"metadata": map[string]any{"name": "sample", "uid": "123"},
+		"spec": map[string]any{
+			"replicas": int64(1),
+			"containers": []any{
+				map[string]any{"name": "app", "image": "nginx:1.24"},
+			},
+		},
+	}
+
+	t.Run("compares every field when desiredOnly is not set", func(t *testing.T) {
+		changes := fields.Compare(desired, live, false)
+
+		assert.Equal(t, []*deviation.Change{
+			{Path: "metadata.uid", Type: deviation.ChangeRemoved, Live: "123"},
+			{Path: "spec.containers[0].image", Type: deviation.ChangeModified, Desired: "nginx:1.25", Live: "nginx:1.24"},
+			{Path: "spec.containers[1]", Type: deviation.ChangeAdded, Desired: map[string]any{"name": "sidecar", "image": "busybox"}},
+			{Path: "spec.paused", Type: deviation.ChangeAdded, Desired: true},
+		}, changes)
+	})
+
+	t.Run("ignores fields present only on live object when desiredOnly is set", func(t *testing.T) {
+		changes := fields.Compare(desired, live, true)
+
+		assert.Len(t, changes, 3)
+		assert.NotContains(t, changes, &deviation.Change{Path: "metadata.uid", Type: deviation.ChangeRemoved, Live: "123"})
+	})
+
+	t.Run("no changes when objects are same", func(t *testing.T) {
+		assert.Empty(t, fields.Compare(live, live, false))
+	})
+}
+
+func TestGetAndRemove(t *testing.T) {
+	newObject := func() map[string]any {
+		return map[string]any{
+			"metadata": map[string]any{
+				"annotations": map[string]any{"kubectl.kubernetes.io/restartedAt": "2026-01-01T00:00:00Z"},
+			},
+			"data": map[string]any{"ca.crt": "certificate", "other": "value"},
+			"spec": map[string]any{
+				"containers": []any{
+					map[string]any{"name": "app", "image": "nginx"},
+					map[string]any{"name": "sidecar", "image": "busybox"},
+				},
+			},
+		}
+	}
+
+	t.Run("gets values with escaped and unescaped keys", func(t *testing.T) {
+		value, found := fields.Get(newObject(), `data.ca\.crt`)
+		assert.True(t, found)
+		assert.Equal(t, "certificate", value)
+
+		value, found = fields.Get(newObject(), "metadata.annotations.kubectl.kubernetes.io/restartedAt")
+		assert.True(t, found)
+		assert.Equal(t, "2026-01-01T00:00:00Z", value)
+
+		_, found = fields.Get(newObject(), "data.missing")
+		assert.False(t, found)
+	})
+
+	t.Run("removes fields matching the path", func(t *testing.T) {
+		object := newObject()
+
+		removed := fields.Remove(object, "data.ca.crt")
+		assert.Equal(t, map[string]any{`data.ca\.crt`: "certificate"}, removed)
+		assert.Equal(t, map[string]any{"other": "value"}, object["data"])
+	})
+
+	t.Run("removes fields from every item of the list", func(t *testing.T) {
+		object := newObject()
+
+		removed := fields.Remove(object, "spec.containers[*].image")
+		assert.Equal(t, map[string]any{"spec.containers[0].image": "nginx", "spec.containers[1].image": "busybox"}, removed)
+
+		_, found := fields.Get(object, "spec.containers[1].image")
+		assert.False(t, found)
+	})
+}
+
+func TestUnder(t *testing.T) {
+	assert.True(t, fields.Under("spec.replicas", "spec.replicas"))
+	assert.True(t, fields.Under("spec.template.spec.containers[1].resources.requests.cpu", "spec.template.spec.containers[*].resources"))
+	assert.True(t, fields.Under(`metadata.annotations.sidecar\.istio\.io/status`, `metadata.annotations.sidecar\.istio\.io/status`))
+	assert.False(t, fields.Under("spec", "spec.replicas"))
+	assert.False(t, fields.Under("spec.replicasCount", "spec.replicas"))
+	assert.False(t, fields.Under("spec.template.spec.containers.resources", "spec.template.spec.containers[*].resources"))
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, fields.Equal(int64(1), float64(1)))
+	assert.True(t, fields.Equal(map[string]any{"key": 1}, map[string]any{"key": int64(1)}))
+	assert.False(t, fields.Equal("1", 1))
+}
@@ -0,0 +1,337 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	driftError "github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"github.com/nikhilsbhat/helm-drift/pkg/fields"
+	"github.com/olekukonko/tablewriter"
+	"github.com/thoas/go-funk"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Results of re-applying the manifest of a drifted resource with Fix.
+const (
+	RemediationRestored = "restored"
+	RemediationDryRun   = "dry-run"
+	RemediationSkipped  = "skipped"
+	RemediationDeclined = "declined"
+	RemediationFailed   = "failed"
+
+	// fixFieldManager is distinct from the field manager of the dry-run applies identifying the drifts, so that the fields restored
+	// are not owned by the latter, which would have the fields dropped from the chart pruned by its dry-run apply instead of reported.
+	fixFieldManager = "helm-drift-fix"
+)
+
+// Remediation holds the result of re-applying the manifest of a drifted resource.
+// Reason is set when the resource was not restored, and holds why.
+type Remediation struct {
+	Kind      string `json:"kind,omitempty"      yaml:"kind,omitempty"`
+	Resource  string `json:"resource,omitempty"  yaml:"resource,omitempty"`
+	NameSpace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Result    string `json:"result,omitempty"    yaml:"result,omitempty"`
+	Reason    string `json:"reason,omitempty"    yaml:"reason,omitempty"`
+}
+
+// FixReport holds the drifted resources of the release, along with the result of re-applying their manifests.
+type FixReport struct {
+	Release      string         `json:"release,omitempty"      yaml:"release,omitempty"`
+	Namespace    string         `json:"namespace,omitempty"    yaml:"namespace,omitempty"`
+	Remediations []*Remediation `json:"remediations,omitempty" yaml:"remediations,omitempty"`
+	TimeSpent    float64        `json:"time_spent,omitempty"   yaml:"time_spent,omitempty"`
+}
+
+// Count returns the number of resources with the result passed.
+func (report *FixReport) Count(result string) int {
+	var count int
+
+	for _, remediation := range report.Remediations {
+		if remediation.Result == result {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Fix identifies the drifts of the release/chart set and re-applies only the manifests of the drifted resources,
+// with server-side apply, from the manifests rendered on to disk. Resources missing from the cluster are re-created.
+// Only the kinds in FixKinds are re-applied when set, when DryRun is set the manifests are applied in dry-run mode
+// and when Interactive is set every resource is confirmed, from the reader set, before it is re-applied.
+// Failing to re-apply a resource does not stop the rest from being re-applied, it is reported as failed instead.
+func (drift *Drift) Fix(ctx context.Context) (report *FixReport, err error) {
+	startTime := time.Now()
+
+	if drift.csv || drift.junit || drift.sarif || drift.html || drift.markdown {
+		return nil, &driftError.DriftError{Message: fmt.Sprintf("results of fixing drifts cannot be rendered as '%s', it should be one of yaml|json|table", drift.OutputFormat)}
+	}
+
+	ctx, cancel := withTimeout(ctx, drift.Timeout)
+	defer cancel()
+
+	if err = drift.cleanManifests(true); err != nil {
+		return nil, &driftError.DriftError{Message: fmt.Sprintf("cleaning old rendered files failed with: %v", err)}
+	}
+
+	if err = drift.setExternalDiff(); err != nil {
+		return nil, err
+	}
+
+	defer func(drift *Drift) {
+		if cleanErr := drift.cleanManifests(false); cleanErr != nil && err == nil {
+			err = &driftError.DriftError{Message: fmt.Sprintf("cleaning rendered files failed with: %v", cleanErr)}
+		}
+	}(drift)
+
+	release, err := drift.diffRelease(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	input := drift.input
+	if input == nil {
+		input = os.Stdin
+	}
+
+	reader := bufio.NewReader(input)
+
+	report = &FixReport{Release: release.Release, Namespace: release.Namespace}
+
+	for _, dvn := range release.Deviations {
+		if !dvn.HasDrift {
+			continue
+		}
+
+		report.Remediations = append(report.Remediations, drift.remediate(ctx, reader, release, dvn))
+	}
+
+	report.TimeSpent = time.Since(startTime).Seconds()
+
+	return report, nil
+}
+
+// remediate re-applies the manifest of the drifted resource, unless it is not allowed to or is declined.
+func (drift *Drift) remediate(ctx context.Context, reader *bufio.Reader, release *deviation.DriftedRelease, dvn *deviation.Deviation) *Remediation {
+	nameSpace := drift.setNameSpace(release, dvn)
+	remediation := &Remediation{Kind: dvn.Kind, Resource: dvn.Resource, NameSpace: nameSpace}
+
+	switch {
+	case dvn.Status == deviation.StatusOrphaned:
+		remediation.Result, remediation.Reason = RemediationSkipped, "orphaned resources are not part of the manifests to be re-applied"
+
+		return remediation
+	case len(drift.FixKinds) != 0 && !funk.ContainsString(drift.FixKinds, dvn.Kind):
+		remediation.Result, remediation.Reason = RemediationSkipped, fmt.Sprintf("kind '%s' is not allowed to be fixed", dvn.Kind)
+
+		return remediation
+	}
+
+	if drift.Interactive {
+		confirmed, err := drift.confirm(reader, dvn, nameSpace)
+		if err != nil {
+			remediation.Result, remediation.Reason = RemediationFailed, err.Error()
+
+			return remediation
+		}
+
+		if !confirmed {
+			remediation.Result = RemediationDeclined
+
+			return remediation
+		}
+	}
+
+	if err := drift.reApply(ctx, dvn, nameSpace); err != nil {
+		drift.log.Errorf("re-applying '%s' '%s' errored with '%v'", dvn.Kind, dvn.Resource, err)
+
+		remediation.Result, remediation.Reason = RemediationFailed, err.Error()
+
+		return remediation
+	}
+
+	remediation.Result = RemediationRestored
+	if drift.DryRun {
+		remediation.Result = RemediationDryRun
+	}
+
+	return remediation
+}
+
+// confirm prints the drifts of the resource and asks whether it should be re-applied, only 'y' or 'yes' confirms it.
+// Running out of input is not a confirmation, so that nothing is re-applied when there is no one to confirm.
+func (drift *Drift) confirm(reader *bufio.Reader, dvn *deviation.Deviation, nameSpace string) (bool, error) {
+	drift.write(addNewLine("------------------------------------------------------------------------------------"))
+	drift.write(addNewLine(fmt.Sprintf("Drifts in: '%s' '%s' from namespace '%s'", dvn.Kind, dvn.Resource, nameSpace)))
+	drift.write(addNewLine("-----------"))
+	drift.write(dvn.Deviations)
+	drift.write(addNewLine("-----------"))
+	drift.write(fmt.Sprintf("Restore '%s' '%s'? [y/N]: ", dvn.Kind, dvn.Resource))
+
+	if err := drift.flush(); err != nil {
+		return false, err
+	}
+
+	answer, err := reader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, &driftError.DriftError{Message: fmt.Sprintf("reading the confirmation to restore '%s' '%s' errored with '%v'", dvn.Kind, dvn.Resource, err)}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// reApply applies the manifest rendered on to disk with server-side apply, taking over the fields changed by others.
+// Fields owned by the mutators targeting the resource are left out, so that restoring them does not undo the mutations (ex: HPA scaling).
+func (drift *Drift) reApply(ctx context.Context, dvn *deviation.Deviation, nameSpace string) error {
+	desired, err := readManifest(dvn.ManifestPath)
+	if err != nil {
+		return err
+	}
+
+	if len(desired.Object) == 0 {
+		return nil
+	}
+
+	if err = drift.dropMutatedFields(ctx, desired, dvn, nameSpace); err != nil {
+		return err
+	}
+
+	resourceClient, err := drift.getResourceClient(desired, nameSpace)
+	if err != nil {
+		return err
+	}
+
+	options := metav1.ApplyOptions{FieldManager: fixFieldManager, Force: true}
+	if drift.DryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+
+	if _, err = resourceClient.Apply(ctx, desired.GetName(), desired, options); err != nil {
+		return &driftError.DriftError{Message: fmt.Sprintf("applying '%s' '%s' errored with '%v'", dvn.Kind, dvn.Resource, err)}
+	}
+
+	return nil
+}
+
+// dropMutatedFields drops the fields owned by the mutators enabled that target the resource from its manifest.
+// Replicas of the workloads scaled by HPA are always dropped, irrespective of the mutators enabled.
+func (drift *Drift) dropMutatedFields(
+	ctx context.Context, desired *unstructured.Unstructured, dvn *deviation.Deviation, nameSpace string,
+) error {
+	mutators := slices.Concat(drift.mutators, drift.customMutators)
+	if !slices.ContainsFunc(mutators, func(mutator *Mutator) bool { return mutator.Name == MutatorHPA }) {
+		mutators = append(mutators, knownMutator(MutatorHPA))
+	}
+
+	targeting, err := drift.mutatorsTargeting(ctx, mutators, dvn, nameSpace)
+	if err != nil {
+		return err
+	}
+
+	for _, mutator := range targeting {
+		for _, path := range mutator.Paths {
+			fields.Remove(desired.Object, path)
+		}
+	}
+
+	return nil
+}
+
+// RenderFix renders the results of re-applying the drifted resources to the writer set, in the OutputFormat set.
+func (drift *Drift) RenderFix(report *FixReport) error {
+	drift.write(addNewLine(""))
+
+	if drift.json || drift.yaml {
+		if err := drift.flush(); err != nil {
+			return err
+		}
+
+		return drift.renderer.Render(report)
+	}
+
+	if drift.table {
+		drift.fixTable(report)
+
+		return drift.flush()
+	}
+
+	drift.printFix(report)
+
+	return drift.flush()
+}
+
+func (drift *Drift) fixTable(report *FixReport) {
+	table := drift.tableSchema()
+	table.SetHeader([]string{"kind", "name", "namespace", "result"})
+
+	for _, remediation := range report.Remediations {
+		tableRow := []string{remediation.Kind, remediation.Resource, remediation.NameSpace, strings.ToUpper(remediation.Result)}
+
+		switch {
+		case drift.NoColor:
+			table.Append(tableRow)
+		case remediation.Result == RemediationFailed:
+			table.Rich(tableRow, []tablewriter.Colors{{}, {}, {}, {tablewriter.FgRedColor}})
+		case remediation.Result == RemediationRestored || remediation.Result == RemediationDryRun:
+			table.Rich(tableRow, []tablewriter.Colors{{}, {}, {}, {tablewriter.FgGreenColor}})
+		default:
+			table.Rich(tableRow, []tablewriter.Colors{{}, {}, {}, {tablewriter.FgYellowColor}})
+		}
+	}
+
+	table.SetCaption(true, drift.getCaption())
+	table.SetHeaderColor(tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold})
+
+	table.Render()
+	drift.write(addNewLine(fmt.Sprintf("Time spent in fixing drifts: '%v'\n", report.TimeSpent)))
+}
+
+func (drift *Drift) printFix(report *FixReport) {
+	drift.write(addNewLine("------------------------------------------------------------------------------------"))
+	drift.write(addNewLine(fmt.Sprintf("Release                                : %s", report.Release)))
+	drift.write(addNewLine("------------------------------------------------------------------------------------"))
+
+	if len(report.Remediations) == 0 {
+		drift.write(addNewLine("YAY...! NO DRIFTS FOUND, NOTHING TO FIX"))
+		drift.write(addNewLine("------------------------------------------------------------------------------------"))
+
+		return
+	}
+
+	for _, remediation := range report.Remediations {
+		message := fmt.Sprintf("%-9s: '%s' '%s' from namespace '%s'",
+			strings.ToUpper(remediation.Result), remediation.Kind, remediation.Resource, remediation.NameSpace)
+		if len(remediation.Reason) != 0 {
+			message = fmt.Sprintf("%s (%s)", message, remediation.Reason)
+		}
+
+		drift.write(addNewLine(message))
+	}
+
+	drift.write(addNewLine("------------------------------------------------------------------------------------"))
+	drift.write(addNewLine(fmt.Sprintf("Total time spent on fixing drifts      : %v", report.TimeSpent)))
+
+	if drift.DryRun {
+		drift.write(addNewLine(fmt.Sprintf("Total number of resources to restore   : %v", report.Count(RemediationDryRun))))
+	} else {
+		drift.write(addNewLine(fmt.Sprintf("Total number of resources restored     : %v", report.Count(RemediationRestored))))
+	}
+
+	drift.write(addNewLine(fmt.Sprintf("Total number of resources failed       : %v", report.Count(RemediationFailed))))
+	drift.write(addNewLine("------------------------------------------------------------------------------------"))
+}
@@ -0,0 +1,179 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/internal/testutil"
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+// newFixDrift returns Drift talking to a fake cluster, that records the patches applied to it.
+func newFixDrift(t *testing.T, answers string) (*Drift, *bytes.Buffer, *[]k8sTesting.PatchActionImpl) {
+	t.Helper()
+
+	patches := make([]k8sTesting.PatchActionImpl, 0)
+
+	dynamicClient := testutil.NewFakeDynamicClient()
+	dynamicClient.PrependReactor("patch", "*", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		patches = append(patches, action.(k8sTesting.PatchActionImpl))
+
+		return true, newDeployment(1), nil
+	})
+
+	buffer := new(bytes.Buffer)
+
+	drift := New(append([]Option{WithDynamicClient(dynamicClient), WithWriter(buffer), WithReader(strings.NewReader(answers))},
+		newFakeClusterOptions()...)...)
+	drift.SetLogger("error")
+
+	return drift, buffer, &patches
+}
+
+func TestRemediate(t *testing.T) {
+	release := &deviation.DriftedRelease{Release: "sample", Namespace: "sample"}
+
+	newDeviation := func(t *testing.T) *deviation.Deviation {
+		t.Helper()
+
+		return &deviation.Deviation{
+			Kind:         "Deployment",
+			Resource:     "sample",
+			HasDrift:     true,
+			Deviations:   "-  replicas: 2\n+  replicas: 1\n",
+			ManifestPath: writeManifest(t, newDeployment(1).Object),
+		}
+	}
+
+	t.Run("should re-apply the manifest with server-side apply", func(t *testing.T) {
+		drift, _, patches := newFixDrift(t, "")
+
+		remediation := drift.remediate(t.Context(), nil, release, newDeviation(t))
+
+		assert.Equal(t, &Remediation{Kind: "Deployment", Resource: "sample", NameSpace: "sample", Result: RemediationRestored}, remediation)
+		require.Len(t, *patches, 1)
+
+		patch := (*patches)[0]
+		assert.Equal(t, types.ApplyPatchType, patch.GetPatchType())
+		assert.Equal(t, "sample", patch.GetNamespace())
+		assert.Equal(t, fixFieldManager, patch.PatchOptions.FieldManager)
+		assert.NotEqual(t, nativeDiffFieldManager, patch.PatchOptions.FieldManager,
+			"the fields restored should not be owned by the field manager identifying the drifts")
+		assert.True(t, *patch.PatchOptions.Force)
+		assert.Empty(t, patch.PatchOptions.DryRun)
+		assert.Contains(t, string(patch.GetPatch()), `"replicas":1`)
+	})
+
+	t.Run("should apply in dry-run mode when enabled", func(t *testing.T) {
+		drift, _, patches := newFixDrift(t, "")
+		drift.DryRun = true
+
+		remediation := drift.remediate(t.Context(), nil, release, newDeviation(t))
+
+		assert.Equal(t, RemediationDryRun, remediation.Result)
+		require.Len(t, *patches, 1)
+		assert.Equal(t, []string{metav1.DryRunAll}, (*patches)[0].PatchOptions.DryRun)
+	})
+
+	t.Run("should skip the kinds not allowed and the orphans", func(t *testing.T) {
+		drift, _, patches := newFixDrift(t, "")
+		drift.FixKinds = []string{"ConfigMap"}
+
+		remediation := drift.remediate(t.Context(), nil, release, newDeviation(t))
+		assert.Equal(t, RemediationSkipped, remediation.Result)
+		assert.Equal(t, "kind 'Deployment' is not allowed to be fixed", remediation.Reason)
+
+		remediation = drift.remediate(t.Context(), nil, release,
+			&deviation.Deviation{Kind: "ConfigMap", Resource: "old", HasDrift: true, Status: deviation.StatusOrphaned})
+		assert.Equal(t, RemediationSkipped, remediation.Result)
+
+		assert.Empty(t, *patches)
+	})
+
+	t.Run("should re-apply only the resources confirmed when interactive", func(t *testing.T) {
+		drift, buffer, patches := newFixDrift(t, "n\nyes\n")
+		drift.Interactive = true
+
+		reader := bufio.NewReader(drift.input)
+
+		assert.Equal(t, RemediationDeclined, drift.remediate(t.Context(), reader, release, newDeviation(t)).Result)
+		assert.Equal(t, RemediationRestored, drift.remediate(t.Context(), reader, release, newDeviation(t)).Result)
+		assert.Len(t, *patches, 1)
+
+		assert.Contains(t, buffer.String(), "Drifts in: 'Deployment' 'sample' from namespace 'sample'")
+		assert.Contains(t, buffer.String(), "+  replicas: 1")
+		assert.Contains(t, buffer.String(), "Restore 'Deployment' 'sample'? [y/N]: ")
+
+		assert.Equal(t, RemediationDeclined, drift.remediate(t.Context(), reader, release, newDeviation(t)).Result,
+			"resources should not be restored once there are no answers left")
+	})
+
+	t.Run("should report the resources that could not be re-applied as failed", func(t *testing.T) {
+		drift, _, _ := newFixDrift(t, "")
+
+		dvn := newDeviation(t)
+		dvn.ManifestPath = writeManifest(t, map[string]any{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]any{"name": "sample"}})
+
+		remediation := drift.remediate(t.Context(), nil, release, dvn)
+		assert.Equal(t, RemediationFailed, remediation.Result)
+		assert.Contains(t, remediation.Reason, "identifying resource for '/v1, Kind=ConfigMap' errored")
+	})
+}
+
+func TestRenderFix(t *testing.T) {
+	report := &FixReport{
+		Release:   "sample",
+		Namespace: "sample",
+		Remediations: []*Remediation{
+			{Kind: "Deployment", Resource: "sample", NameSpace: "sample", Result: RemediationRestored},
+			{Kind: "Secret", Resource: "credentials", NameSpace: "sample", Result: RemediationFailed, Reason: "forbidden"},
+			{Kind: "ConfigMap", Resource: "old", NameSpace: "sample", Result: RemediationSkipped, Reason: "orphaned"},
+		},
+	}
+
+	t.Run("should print what was restored", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+		drift := Drift{NoColor: true}
+		drift.SetLogger("error")
+		drift.SetWriter(buffer)
+		require.NoError(t, drift.SetOutputFormats())
+
+		require.NoError(t, drift.RenderFix(report))
+
+		assert.Contains(t, buffer.String(), "RESTORED : 'Deployment' 'sample' from namespace 'sample'")
+		assert.Contains(t, buffer.String(), "FAILED   : 'Secret' 'credentials' from namespace 'sample' (forbidden)")
+		assert.Contains(t, buffer.String(), "Total number of resources restored     : 1")
+		assert.Contains(t, buffer.String(), "Total number of resources failed       : 1")
+	})
+
+	t.Run("should render what was restored as table", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+		drift := Drift{NoColor: true, OutputFormat: "table"}
+		drift.SetLogger("error")
+		drift.SetWriter(buffer)
+		require.NoError(t, drift.SetOutputFormats())
+
+		require.NoError(t, drift.RenderFix(report))
+
+		assert.Contains(t, buffer.String(), "RESTORED")
+		assert.Contains(t, buffer.String(), "credentials")
+	})
+
+	t.Run("should report nothing to fix when there are no drifts", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+		drift := Drift{NoColor: true}
+		drift.SetLogger("error")
+		drift.SetWriter(buffer)
+
+		require.NoError(t, drift.RenderFix(&FixReport{Release: "sample"}))
+		assert.Contains(t, buffer.String(), "NO DRIFTS FOUND, NOTHING TO FIX")
+	})
+}
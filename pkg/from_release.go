@@ -1,6 +1,7 @@
 package pkg
 
 import (
+	"context"
 	"log"
 	"os"
 
@@ -12,14 +13,24 @@ import (
 )
 
 // getChartFromRelease should get the manifest from the selected release.
-func (drift *Drift) getChartFromRelease() ([]byte, error) {
+func (drift *Drift) getChartFromRelease(ctx context.Context) ([]byte, error) {
+	return drift.getReleaseManifest(ctx, drift.kubeContext, "", drift.release, drift.Revision)
+}
+
+// getReleaseManifest gets the manifest from the revision of the release, from the namespace and the kube context passed.
+// The namespace helm is set to is used when the namespace is not passed.
+// Since the helm client takes no context, the release is fetched in the background and is abandoned once the context is done.
+func (drift *Drift) getReleaseManifest(ctx context.Context, kubeContext, nameSpace, releaseName string, revision int) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	settings := cli.New()
 
-	drift.log.Debugf("fetching chart manifest for release '%s' from kube cluster", drift.release)
+	drift.log.Debugf("fetching chart manifest for release '%s' from kube cluster", releaseName)
 
 	actionConfig := new(action.Configuration)
 
-	kubeContext := drift.kubeContext
 	if len(kubeContext) != 0 {
 		settings.KubeContext = kubeContext
 	}
@@ -28,7 +39,11 @@ func (drift *Drift) getChartFromRelease() ([]byte, error) {
 		settings.KubeConfig = drift.kubeConfig
 	}
 
-	if err := actionConfig.Init(settings.RESTClientGetter(), settings.Namespace(), os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
+	if len(nameSpace) == 0 {
+		nameSpace = settings.Namespace()
+	}
+
+	if err := actionConfig.Init(settings.RESTClientGetter(), nameSpace, os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
 		drift.log.Error("oops initialising helm client errored with", err)
 
 		return nil, err
@@ -36,17 +51,37 @@ func (drift *Drift) getChartFromRelease() ([]byte, error) {
 
 	client := action.NewGet(actionConfig)
 
-	drift.log.Debugf("fetching manifests from revision '%d' of helm release '%s'", drift.Revision, drift.release)
-	client.Version = drift.Revision
+	drift.log.Debugf("fetching manifests from revision '%d' of helm release '%s'", revision, releaseName)
+	client.Version = revision
+
+	type fetchedRelease struct {
+		release *release.Release
+		err     error
+	}
+
+	fetched := make(chan fetchedRelease, 1)
+
+	go func() {
+		helmRelease, err := client.Run(releaseName)
+		fetched <- fetchedRelease{release: helmRelease, err: err}
+	}()
+
+	var result fetchedRelease
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result = <-fetched:
+	}
 
-	helmRelease, err := client.Run(drift.release)
+	helmRelease, err := result.release, result.err
 	if err != nil {
-		drift.log.Errorf("fetching helm release '%s' errored with '%v'", drift.release, err)
+		drift.log.Errorf("fetching helm release '%s' errored with '%v'", releaseName, err)
 
 		return nil, err
 	}
 
-	drift.log.Debugf("chart manifest for release '%s' was successfully retrieved from kube cluster", drift.release)
+	drift.log.Debugf("chart manifest for release '%s' was successfully retrieved from kube cluster", releaseName)
 
 	return []byte(helmRelease.Manifest), nil
 }
@@ -13,9 +13,14 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-const helmTemplateBaseArgCount = 3
+const (
+	helmTemplateBaseArgCount = 3
+	templateSourcePrefix     = "# Source: "
+)
+
+var templateSourceRegex = regexp.MustCompile(`(?m)^# Source:\s*(\S+)`)
 
-func (drift *Drift) getChartFromTemplate() ([]byte, error) {
+func (drift *Drift) getChartFromTemplate(ctx context.Context) ([]byte, error) {
 	flags := make([]string, 0)
 	for _, value := range drift.Values {
 		flags = append(flags, "--set", value)
@@ -63,8 +68,10 @@ func (drift *Drift) getChartFromTemplate() ([]byte, error) {
 
 	drift.log.Debugf("rendering helm chart with following commands/flags '%s'", strings.Join(args, ", "))
 
-	cmd := exec.CommandContext(context.Background(), os.Getenv("HELM_BIN"), args...) //nolint:gosec
-	output, err := cmd.Output()
+	cmd := drift.newCommand(ctx, helmBin())
+	cmd.SetHelmCmd(args...)
+
+	output, err := cmd.RunHelmCmd()
 
 	var exitErr *exec.ExitError
 
@@ -82,9 +89,25 @@ func (drift *Drift) getChartFromTemplate() ([]byte, error) {
 		return nil, fmt.Errorf("%w: %s", pathErr, pathErr.Path)
 	}
 
+	if err != nil {
+		drift.log.Errorf("rendering template for release: '%s' errored with %v", drift.release, err)
+
+		return nil, err
+	}
+
 	return output, nil
 }
 
+// helmBin returns the helm cli to render the charts with, it is the one running helm drift as a plugin (HELM_BIN),
+// or the one on PATH otherwise.
+func helmBin() string {
+	if helmBin := os.Getenv("HELM_BIN"); len(helmBin) != 0 {
+		return helmBin
+	}
+
+	return "helm"
+}
+
 func (drift *Drift) getTemplates(template []byte) []string {
 	drift.log.Debugf("splitting helm manifests with regex pattern: '%s'", drift.Regex)
 	temp := regexp.MustCompile(drift.Regex)
@@ -92,5 +115,24 @@ func (drift *Drift) getTemplates(template []byte) []string {
 	// Removing empty string at the beginning as splitting string always adds it in front.
 	kinds = kinds[1:]
 
+	// The source of the manifest is split off along with the separator, it is added back as a comment so that
+	// the template the manifest is rendered from is known.
+	for index, separator := range temp.FindAllString(string(template), -1) {
+		if source := templateSource(separator); len(source) != 0 && index < len(kinds) {
+			kinds[index] = templateSourcePrefix + source + kinds[index]
+		}
+	}
+
 	return kinds
 }
+
+// templateSource returns the path of the template from the '# Source:' comment of the manifest rendered by helm
+// (ex: sample/templates/deployment.yaml), it is empty when the manifest has none.
+func templateSource(manifest string) string {
+	matches := templateSourceRegex.FindStringSubmatch(manifest)
+	if len(matches) == 0 {
+		return ""
+	}
+
+	return matches[1]
+}
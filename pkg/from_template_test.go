@@ -3,7 +3,9 @@ package pkg
 import (
 	"testing"
 
+	"github.com/nikhilsbhat/helm-drift/internal/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetTemplates(t *testing.T) {
@@ -27,4 +29,38 @@ metadata:
 	assert.Len(t, templates, 2)
 	assert.Contains(t, templates[0], "kind: Deployment")
 	assert.Contains(t, templates[1], "kind: Service")
+	assert.Equal(t, "sample/templates/deployment.yaml", templateSource(templates[0]), "source of the manifest should be retained")
+	assert.Equal(t, "sample/templates/service.yaml", templateSource(templates[1]))
+}
+
+func TestGetChartFromTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		helmBin  string
+		expected string
+	}{
+		{name: "should render the chart with the helm running helm drift as a plugin", helmBin: "/usr/local/bin/helm", expected: "/usr/local/bin/helm"},
+		{name: "should render the chart with the helm on PATH when not run as a plugin", helmBin: "", expected: "helm"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("HELM_BIN", test.helmBin)
+
+			exec := &testutil.FakeExec{Output: "---\n# Source: sample/templates/deployment.yaml\nkind: Deployment\n"}
+
+			drift := New(WithCommandExecutor(exec.Executor()))
+			drift.SetLogger("error")
+			drift.SetRelease("sample")
+			drift.SetChart("./sample")
+			drift.Version = "1.0.0"
+
+			output, err := drift.getChartFromTemplate(t.Context())
+			require.NoError(t, err)
+
+			assert.Equal(t, exec.Output, string(output))
+			assert.Equal(t, test.expected, exec.Cmd)
+			assert.Equal(t, []string{"template", "sample", "./sample", "--include-crds", "--version", "1.0.0"}, exec.Args)
+		})
+	}
 }
@@ -2,7 +2,6 @@ package pkg
 
 import (
 	"errors"
-	"log"
 
 	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
 	driftError "github.com/nikhilsbhat/helm-drift/pkg/errors"
@@ -22,64 +21,82 @@ func NewHelmTemplates(templates []string) *HelmTemplates {
 	return &helmTemplates
 }
 
-func (templates *HelmTemplates) FilterBySkip(drift *Drift) []string {
-	return funk.Filter(*templates, func(tmpl string) bool {
-		if len(drift.SkipKinds) == 0 {
-			return true
-		}
+func (templates *HelmTemplates) FilterBySkip(drift *Drift) ([]string, error) {
+	if len(drift.SkipKinds) == 0 {
+		return *templates, nil
+	}
 
+	return templates.filter(func(tmpl string) (bool, error) {
 		kind, err := k8s.NewResource().Get(tmpl, "kind", drift.log)
 		if err != nil {
-			log.Fatal(err)
+			return false, err
 		}
 
-		return !funk.Contains(drift.SkipKinds, kind)
-	}).([]string)
+		return !funk.Contains(drift.SkipKinds, kind), nil
+	})
 }
 
-func (templates *HelmTemplates) FilterByKind(drift *Drift) []string {
-	return funk.Filter(*templates, func(tmpl string) bool {
-		if len(drift.Kind) == 0 {
-			return true
-		}
+func (templates *HelmTemplates) FilterByKind(drift *Drift) ([]string, error) {
+	if len(drift.Kind) == 0 {
+		return *templates, nil
+	}
 
+	return templates.filter(func(tmpl string) (bool, error) {
 		kind, err := k8s.NewResource().Get(tmpl, "kind", drift.log)
 		if err != nil {
-			log.Fatal(err)
+			return false, err
 		}
 
-		return funk.Contains(drift.Kind, kind)
-	}).([]string)
+		return funk.Contains(drift.Kind, kind), nil
+	})
 }
 
-func (templates *HelmTemplates) FilterByName(drift *Drift) []string {
-	return funk.Filter(*templates, func(tmpl string) bool {
-		if len(drift.Name) == 0 {
-			return true
-		}
+func (templates *HelmTemplates) FilterByName(drift *Drift) ([]string, error) {
+	if len(drift.Name) == 0 {
+		return *templates, nil
+	}
 
+	return templates.filter(func(tmpl string) (bool, error) {
 		name, err := k8s.NewResource().GetMetadata(tmpl, "name", drift.log)
 		if err != nil {
-			log.Fatal(err)
+			return false, err
 		}
 
-		return name == drift.Name
-	}).([]string)
+		return name == drift.Name, nil
+	})
 }
 
-func (templates *HelmTemplates) FilterByHelmHook(drift *Drift) []string {
+func (templates *HelmTemplates) FilterByHelmHook(drift *Drift) ([]string, error) {
 	if drift.ConsiderHooks {
-		return *templates
+		return *templates, nil
 	}
 
-	return funk.Filter(*templates, func(tmpl string) bool {
+	return templates.filter(func(tmpl string) (bool, error) {
 		hook, err := k8s.NewResource().IsHelmHook(tmpl, drift.IgnoreHookTypes)
 		if err != nil {
-			log.Fatal(err)
+			return false, err
+		}
+
+		return !hook, nil
+	})
+}
+
+// filter retains the templates for which the predicate holds true, it stops at the first template the predicate errors on.
+func (templates *HelmTemplates) filter(predicate func(tmpl string) (bool, error)) ([]string, error) {
+	filtered := make([]string, 0, len(*templates))
+
+	for _, tmpl := range *templates {
+		retain, err := predicate(tmpl)
+		if err != nil {
+			return nil, err
+		}
+
+		if retain {
+			filtered = append(filtered, tmpl)
 		}
+	}
 
-		return !hook
-	}).([]string)
+	return filtered, nil
 }
 
 func (templates *HelmTemplates) Get(log *logrus.Logger) ([]*deviation.Deviation, error) {
@@ -38,11 +38,24 @@ func TestHelmTemplatesFilters(t *testing.T) {
 
 	templates := NewHelmTemplates([]string{deploymentManifest, serviceManifest, hookManifest})
 
-	filtered := NewHelmTemplates(templates.FilterByHelmHook(&drift)).FilterByKind(&drift)
-	filtered = NewHelmTemplates(filtered).FilterBySkip(&drift)
-	filtered = NewHelmTemplates(filtered).FilterByName(&drift)
+	filtered, err := templates.FilterByHelmHook(&drift)
+	require.NoError(t, err)
+
+	filtered, err = NewHelmTemplates(filtered).FilterByKind(&drift)
+	require.NoError(t, err)
+
+	filtered, err = NewHelmTemplates(filtered).FilterBySkip(&drift)
+	require.NoError(t, err)
+
+	filtered, err = NewHelmTemplates(filtered).FilterByName(&drift)
+	require.NoError(t, err)
 
 	assert.Equal(t, []string{deploymentManifest}, filtered)
+
+	t.Run("should error when the template could not be parsed", func(t *testing.T) {
+		_, err = NewHelmTemplates([]string{"kind: [Deployment"}).FilterByKind(&drift)
+		require.Error(t, err)
+	})
 }
 
 func TestHelmTemplateGet(t *testing.T) {
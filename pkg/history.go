@@ -0,0 +1,199 @@
+package pkg
+
+import (
+	"context"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// noRevision is set as the current revision, when the drifts are identified from the chart and not from one of the revisions.
+	noRevision = 0
+	// latestRevision is set as the current revision, when the drifts are identified from the latest revision of the release.
+	latestRevision = -1
+)
+
+// revisionManifests holds the manifests of a revision of the release, indexed by their kind and name.
+type revisionManifests struct {
+	version   int
+	manifests map[string]map[string]any
+}
+
+// currentRevision returns the revision of the release from which the drifts are identified.
+func (drift *Drift) currentRevision() int {
+	switch {
+	case !drift.FromRelease:
+		return noRevision
+	case drift.Revision != 0:
+		return drift.Revision
+	default:
+		return latestRevision
+	}
+}
+
+// setMatchedRevisions walks through the revisions of the release other than the current one, and sets on every drifted resource
+// the latest of the revisions whose manifest matches its live state. A resource matching none of them was likely edited in place,
+// whereas a resource matching an older revision was likely reverted to it (ex: a rollback gone wrong).
+// Failing to fetch the history of the release does not fail identifying drifts, the revisions are left unset instead.
+func (drift *Drift) setMatchedRevisions(ctx context.Context, driftedRelease *deviation.DriftedRelease, currentRevision int) {
+	if !driftedRelease.HasDrift {
+		return
+	}
+
+	revisions, err := drift.getReleaseHistory(driftedRelease.Release, driftedRelease.Namespace)
+	if err != nil {
+		drift.log.Warnf("fetching history of release '%s' errored with '%v', hence revisions matching the live state are not identified",
+			driftedRelease.Release, err)
+
+		return
+	}
+
+	drift.matchRevisions(ctx, driftedRelease, revisions, currentRevision)
+}
+
+// matchRevisions sets on every drifted resource of the release, the latest of the revisions other than the current one
+// whose manifest matches its live state.
+func (drift *Drift) matchRevisions(ctx context.Context, driftedRelease *deviation.DriftedRelease, revisions []*release.Release, currentRevision int) {
+	history := drift.indexRevisions(driftedRelease, revisions, currentRevision)
+
+	for _, dvn := range driftedRelease.Deviations {
+		if !dvn.HasDrift || len(dvn.Status) != 0 {
+			continue
+		}
+
+		nameSpace := drift.setNameSpace(driftedRelease, dvn)
+
+		matchedRevision, err := drift.matchRevision(ctx, history, dvn, nameSpace)
+		if err != nil {
+			drift.log.Warnf("matching '%s' '%s' against the history of the release errored with '%v'", dvn.Kind, dvn.Resource, err)
+
+			continue
+		}
+
+		dvn.MatchedRevision = matchedRevision
+		if dvn.MatchedRevision != 0 {
+			drift.log.Debugf("live state of '%s' '%s' matches revision '%d' of release '%s'",
+				dvn.Kind, dvn.Resource, dvn.MatchedRevision, driftedRelease.Release)
+		}
+	}
+}
+
+// matchRevision returns the version of the first of the revisions whose manifest of the resource matches its live object.
+// The manifest matches when applying it leaves the live object as it is, hence the object the API server would persist
+// if it was applied (server-side apply in dry-run mode) is compared against the live object, both canonicalised by the API server.
+// It returns 0 when none of them match, or when the resource has no live object.
+func (drift *Drift) matchRevision(ctx context.Context, history []*revisionManifests, dvn *deviation.Deviation, nameSpace string) (int, error) {
+	for _, revision := range history {
+		manifest, ok := revision.manifests[manifestKey(dvn.Kind, dvn.Resource)]
+		if !ok {
+			continue
+		}
+
+		live, merged, err := drift.dryRunApply(ctx, (&unstructured.Unstructured{Object: manifest}).DeepCopy(), dvn, nameSpace)
+		if err != nil {
+			return 0, err
+		}
+
+		if live == nil {
+			return 0, nil
+		}
+
+		for _, object := range []*unstructured.Unstructured{live, merged} {
+			if err = drift.normalize(object); err != nil {
+				return 0, err
+			}
+		}
+
+		if len(objectChanges(merged, live, false)) == 0 {
+			return revision.version, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// indexRevisions parses the manifests of the revisions other than the current one, latest revision first.
+// The fields ignored by the ignore rules are removed from them, the same way they are from the manifests rendered on to disk.
+func (drift *Drift) indexRevisions(driftedRelease *deviation.DriftedRelease, revisions []*release.Release, currentRevision int) []*revisionManifests {
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Version > revisions[j].Version
+	})
+
+	if currentRevision == latestRevision && len(revisions) != 0 {
+		currentRevision = revisions[0].Version
+	}
+
+	history := make([]*revisionManifests, 0, len(revisions))
+
+	for _, revision := range revisions {
+		if revision.Version == currentRevision || len(revision.Manifest) == 0 {
+			continue
+		}
+
+		manifests := make(map[string]map[string]any)
+
+		for _, manifest := range drift.getTemplates([]byte(revision.Manifest)) {
+			template, err := NewHelmTemplate(manifest).Get(drift.log)
+			if err != nil {
+				drift.log.Debugf("skipping a manifest of revision '%d' of release '%s' as it could not be parsed: %v",
+					revision.Version, driftedRelease.Release, err)
+
+				continue
+			}
+
+			manifest, _, err = drift.applyIgnoreRules(manifest, template, driftedRelease.Release, driftedRelease.Namespace)
+			if err != nil {
+				continue
+			}
+
+			if manifest, err = drift.normalizeManifest(manifest, template); err != nil {
+				continue
+			}
+
+			object := make(map[string]any)
+			if err = yaml.Unmarshal([]byte(manifest), &object); err != nil || len(object) == 0 {
+				continue
+			}
+
+			manifests[manifestKey(template.Kind, template.Resource)] = object
+		}
+
+		history = append(history, &revisionManifests{version: revision.Version, manifests: manifests})
+	}
+
+	return history
+}
+
+// getReleaseHistory fetches all the revisions of the release, that are retained by helm.
+func (drift *Drift) getReleaseHistory(releaseName, nameSpace string) ([]*release.Release, error) {
+	settings := cli.New()
+
+	if len(drift.kubeContext) != 0 {
+		settings.KubeContext = drift.kubeContext
+	}
+
+	if len(drift.kubeConfig) != 0 {
+		settings.KubeConfig = drift.kubeConfig
+	}
+
+	drift.log.Debugf("fetching history of helm release '%s' from namespace '%s'", releaseName, nameSpace)
+
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(settings.RESTClientGetter(), nameSpace, os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
+		return nil, err
+	}
+
+	return action.NewHistory(actionConfig).Run(releaseName)
+}
+
+func manifestKey(kind, name string) string {
+	return kind + "/" + name
+}
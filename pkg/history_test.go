@@ -0,0 +1,134 @@
+package pkg
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/internal/testutil"
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sTesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+)
+
+func newRevision(t *testing.T, version int, replicas int64) *release.Release {
+	t.Helper()
+
+	manifest, err := yaml.Marshal(newDeployment(replicas).Object)
+	require.NoError(t, err)
+
+	return &release.Release{Name: "sample", Version: version, Manifest: "---\n# Source: sample/templates/deployment.yaml\n" + string(manifest)}
+}
+
+func TestMatchRevisions(t *testing.T) {
+	newRelease := func(t *testing.T) *deviation.DriftedRelease {
+		t.Helper()
+
+		return &deviation.DriftedRelease{
+			Release:   "sample",
+			Namespace: "sample",
+			HasDrift:  true,
+			Deviations: []*deviation.Deviation{
+				{Kind: "Deployment", Resource: "sample", HasDrift: true, ManifestPath: writeManifest(t, newDeployment(1).Object)},
+			},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		liveReplicas    int64
+		currentRevision int
+		expected        int
+	}{
+		{name: "should match the latest of the older revisions matching the live state", liveReplicas: 2, currentRevision: latestRevision, expected: 2},
+		{name: "should match none of the revisions when the live state was edited in place", liveReplicas: 5, currentRevision: latestRevision, expected: 0},
+		{name: "should not match the revision from which the drifts are identified", liveReplicas: 1, currentRevision: 3, expected: 0},
+		{name: "should consider all the revisions when the drifts are identified from the chart", liveReplicas: 1, currentRevision: noRevision, expected: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			drift := New(newFakeClusterOptions(newDeployment(test.liveReplicas))...)
+			drift.SetLogger("error")
+
+			revisions := []*release.Release{newRevision(t, 1, 2), newRevision(t, 3, 1), newRevision(t, 2, 2)}
+
+			driftedRelease := newRelease(t)
+			drift.matchRevisions(t.Context(), driftedRelease, revisions, test.currentRevision)
+
+			assert.Equal(t, test.expected, driftedRelease.Deviations[0].MatchedRevision)
+		})
+	}
+}
+
+func TestMatchRevisionCanonicalised(t *testing.T) {
+	newCPUDeployment := func(cpu string) map[string]any {
+		deployment := newDeployment(2)
+		_ = unstructured.SetNestedSlice(deployment.Object, []any{
+			map[string]any{"name": "app", "resources": map[string]any{"requests": map[string]any{"cpu": cpu}}},
+		}, "spec", "template", "spec", "containers")
+
+		return deployment.Object
+	}
+
+	live := &unstructured.Unstructured{Object: newCPUDeployment("1")}
+
+	dynamicClient := testutil.NewFakeDynamicClient(live)
+	dynamicClient.PrependReactor("patch", "*", func(_ k8sTesting.Action) (bool, runtime.Object, error) {
+		// the API server canonicalises the cpu '1000m' of the manifest applied to '1'.
+		return true, live.DeepCopy(), nil
+	})
+
+	drift := New(append([]Option{WithDynamicClient(dynamicClient)}, newFakeClusterOptions()...)...)
+	drift.SetLogger("error")
+
+	history := []*revisionManifests{{version: 2, manifests: map[string]map[string]any{"Deployment/sample": newCPUDeployment("1000m")}}}
+
+	matchedRevision, err := drift.matchRevision(t.Context(), history, &deviation.Deviation{Kind: "Deployment", Resource: "sample"}, "sample")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, matchedRevision)
+}
+
+func TestRenderMatchedRevision(t *testing.T) {
+	drifts := []*deviation.DriftedRelease{{
+		Release:  "release",
+		HasDrift: true,
+		Deviations: []*deviation.Deviation{
+			{Kind: "Deployment", Resource: "rolled-back", HasDrift: true, MatchedRevision: 2},
+			{Kind: "Deployment", Resource: "edited", HasDrift: true},
+			{Kind: "Service", Resource: "sample"},
+		},
+	}}
+
+	t.Run("should print the revisions matching the live state", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+		drift := Drift{NoColor: true, History: true}
+		drift.SetLogger("error")
+		drift.SetWriter(buffer)
+
+		drift.print(drifts)
+		require.NoError(t, drift.flush())
+
+		assert.Contains(t, buffer.String(), "Live state matches revision '2' of the release")
+		assert.Contains(t, buffer.String(), "Live state matches none of the other revisions of the release, it was likely edited in place")
+	})
+
+	t.Run("should render the revisions matching the live state as table", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+		drift := Drift{History: true, OutputFormat: "table"}
+		drift.SetLogger("error")
+		drift.SetWriter(buffer)
+		require.NoError(t, drift.SetOutputFormats())
+
+		drift.toTABLE(drifts)
+		require.NoError(t, drift.flush())
+
+		assert.Contains(t, buffer.String(), "MATCHED REVISION")
+		assert.Contains(t, buffer.String(), "none")
+	})
+}
@@ -1,32 +1,29 @@
 package pkg
 
 import (
-	"bufio"
 	"context"
 	"fmt"
-	"os"
-	"strings"
 
-	"github.com/acarl005/stripansi"
 	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-const (
-	toolDiff = "diff"
-	toolDyff = "dyff"
-)
-
-func (drift *Drift) IsManagedByHPA(name, kind, nameSpace string) (bool, error) {
-	hpaTargets, err := drift.hpaTargets(nameSpace)
+// IsManagedByHPA returns true when the resource is the target of any of the HorizontalPodAutoscalers in the namespace.
+func (drift *Drift) IsManagedByHPA(ctx context.Context, name, kind, nameSpace string) (bool, error) {
+	hpaTargets, err := drift.cachedTargets(ctx, MutatorHPA, nameSpace, hpaTargets)
 	if err != nil {
 		return false, err
 	}
 
-	_, isManagedByHPA := hpaTargets[hpaTargetKey(name, kind)]
+	_, isManagedByHPA := hpaTargets[targetKey(name, kind)]
 	if isManagedByHPA {
 		drift.log.Debugf("the '%s' '%s' is managed by hpa hence the drifts for this would be suppressed if enabled", kind, name)
 	}
@@ -34,49 +31,31 @@ func (drift *Drift) IsManagedByHPA(name, kind, nameSpace string) (bool, error) {
 	return isManagedByHPA, nil
 }
 
-func (drift *Drift) hpaTargets(nameSpace string) (map[string]struct{}, error) {
-	drift.hpaCacheMu.RLock()
-
-	if hpaTargets, ok := drift.hpaCache[nameSpace]; ok {
-		drift.hpaCacheMu.RUnlock()
-
-		return hpaTargets, nil
-	}
-
-	drift.hpaCacheMu.RUnlock()
-
+func hpaTargets(ctx context.Context, drift *Drift, nameSpace string) (map[string]struct{}, error) {
 	clientSet, err := drift.getKubeClient()
 	if err != nil {
 		return nil, err
 	}
 
 	response, err := clientSet.AutoscalingV2().HorizontalPodAutoscalers(nameSpace).
-		List(context.TODO(), metav1.ListOptions{})
+		List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
 
-	hpaTargets := make(map[string]struct{}, len(response.Items))
+	targets := make(map[string]struct{}, len(response.Items))
 	for _, item := range response.Items {
-		hpaTargets[hpaTargetKey(item.Spec.ScaleTargetRef.Name, item.Spec.ScaleTargetRef.Kind)] = struct{}{}
-	}
-
-	drift.hpaCacheMu.Lock()
-	if drift.hpaCache == nil {
-		drift.hpaCache = make(map[string]map[string]struct{})
+		targets[targetKey(item.Spec.ScaleTargetRef.Name, item.Spec.ScaleTargetRef.Kind)] = struct{}{}
 	}
 
-	drift.hpaCache[nameSpace] = hpaTargets
-	drift.hpaCacheMu.Unlock()
-
-	return hpaTargets, nil
+	return targets, nil
 }
 
 func (drift *Drift) getKubeClient() (kubernetes.Interface, error) {
 	drift.kubeClientOnce.Do(func() {
-		config, err := buildConfigWithContextFromFlags(drift.kubeContext, drift.kubeConfig)
+		config, err := drift.getRestConfig()
 		if err != nil {
-			drift.kubeClientErr = &errors.DriftError{Message: fmt.Sprintf("building config with context errored with '%v'", err)}
+			drift.kubeClientErr = err
 
 			return
 		}
@@ -90,93 +69,78 @@ func (drift *Drift) getKubeClient() (kubernetes.Interface, error) {
 	return drift.kubeClient, drift.kubeClientErr
 }
 
-func buildConfigWithContextFromFlags(context string, kubeConfigPath string) (*rest.Config, error) {
-	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeConfigPath},
-		&clientcmd.ConfigOverrides{
-			CurrentContext: context,
-		}).ClientConfig()
-}
-
-func hpaTargetKey(name, kind string) string {
-	return kind + "/" + name
-}
-
-func (drift *Drift) HasOnlyChangesScaledByHpa(diffOutput string) (bool, error) {
-	customDiff := drift.CustomDiff
-	if customDiff == "" {
-		customDiff = os.Getenv("KUBECTL_EXTERNAL_DIFF")
-	}
+func (drift *Drift) getDynamicClient() (dynamic.Interface, error) {
+	drift.dynamicClientOnce.Do(func() {
+		config, err := drift.getRestConfig()
+		if err != nil {
+			drift.dynamicClientErr = err
 
-	diffToolUsed := toolDiff
-	if customDiff != "" {
-		diffToolUsed = strings.Split(customDiff, " ")[0]
-	}
+			return
+		}
 
-	drift.log.Infof("custom diff: %s", diffToolUsed)
+		drift.dynamicClient, drift.dynamicClientErr = dynamic.NewForConfig(config)
+		if drift.dynamicClientErr != nil {
+			drift.dynamicClientErr = &errors.DriftError{Message: fmt.Sprintf("creating kubernetes dynamic client errored with '%v'", drift.dynamicClientErr)}
+		}
+	})
 
-	if diffToolUsed != toolDiff && diffToolUsed != toolDyff {
-		drift.log.Warnf("--ignore-hpa-changes currently only supports %s and %s, not '%s'", toolDiff, toolDyff, diffToolUsed)
+	return drift.dynamicClient, drift.dynamicClientErr
+}
 
-		return false, nil
-	}
+func (drift *Drift) getMetadataClient() (metadata.Interface, error) {
+	drift.metadataClientOnce.Do(func() {
+		config, err := drift.getRestConfig()
+		if err != nil {
+			drift.metadataClientErr = err
 
-	diffOutput = stripansi.Strip(diffOutput)
+			return
+		}
 
-	scanner := bufio.NewScanner(strings.NewReader(diffOutput))
+		drift.metadataClient, drift.metadataClientErr = metadata.NewForConfig(config)
+		if drift.metadataClientErr != nil {
+			drift.metadataClientErr = &errors.DriftError{
+				Message: fmt.Sprintf("creating kubernetes metadata client errored with '%v'", drift.metadataClientErr),
+			}
+		}
+	})
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	return drift.metadataClient, drift.metadataClientErr
+}
 
-		isNonHpaChange := (diffToolUsed == toolDiff && diffLineHasChangesNonHpaRelated(line)) ||
-			(diffToolUsed == toolDyff && dyffLineHasChangesNonHpaRelated(line))
+func (drift *Drift) getRESTMapper() (meta.RESTMapper, error) {
+	drift.restMapperOnce.Do(func() {
+		clientSet, err := drift.getKubeClient()
+		if err != nil {
+			drift.restMapperErr = err
 
-		if !isNonHpaChange {
-			continue
+			return
 		}
 
-		return false, nil
-	}
-
-	if err := scanner.Err(); err != nil {
-		return false, err
-	}
+		drift.restMapper = restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientSet.Discovery()))
+	})
 
-	return true, nil
+	return drift.restMapper, drift.restMapperErr
 }
 
-func diffLineHasChangesNonHpaRelated(line string) bool {
-	// skip diff output lines starting with +++ or ---
-	if strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
-		return false
-	}
-
-	// skip lines that have no changes
-	if !strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "-") {
-		return false
-	}
-
-	// check if the line changed is related to replicas or generation, then continue,
-	// since we are looking for other fields changed besides replicas and generation
-	if strings.Contains(line, "+  replicas:") || strings.Contains(line, "-  replicas:") ||
-		strings.Contains(line, "+  generation:") || strings.Contains(line, "-  generation:") {
-		return false
-	}
+func (drift *Drift) getRestConfig() (*rest.Config, error) {
+	drift.restConfigOnce.Do(func() {
+		drift.restConfig, drift.restConfigErr = buildConfigWithContextFromFlags(drift.kubeContext, drift.kubeConfig)
+		if drift.restConfigErr != nil {
+			drift.restConfigErr = &errors.DriftError{Message: fmt.Sprintf("building config with context errored with '%v'", drift.restConfigErr)}
+		}
+	})
 
-	return true
+	return drift.restConfig, drift.restConfigErr
 }
 
-func dyffLineHasChangesNonHpaRelated(line string) bool {
-	// skip empty lines and lines starting with space
-	if line == "" || strings.HasPrefix(line, " ") {
-		return false
-	}
-
-	// check if the line changed is related to replicas or generation, then continue,
-	// since we are looking for other fields changed besides replicas and generation
-	if strings.Contains(line, "spec.replicas") || strings.Contains(line, "metadata.generation") {
-		return false
-	}
+func buildConfigWithContextFromFlags(context string, kubeConfigPath string) (*rest.Config, error) {
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeConfigPath},
+		&clientcmd.ConfigOverrides{
+			CurrentContext: context,
+		}).ClientConfig()
+}
 
-	return true
+func targetKey(name, kind string) string {
+	return kind + "/" + name
 }
@@ -0,0 +1,200 @@
+package pkg
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+)
+
+// Types of the cells of the side-by-side diffs, used as the classes styling them.
+const (
+	htmlCellContext = "context"
+	htmlCellRemoved = "removed"
+	htmlCellAdded   = "added"
+	htmlCellEmpty   = "empty"
+)
+
+//go:embed html_report.tmpl
+var htmlReportTemplate string
+
+// htmlReport holds the drifts in the shape rendered by the HTML report.
+type htmlReport struct {
+	TimeSpent  float64
+	Releases   []*htmlRelease
+	Drifted    int
+	Resources  int
+	Drifts     int
+	Statuses   []string
+	Namespaces []string
+	Kinds      []string
+	LeftLabel  string
+	RightLabel string
+}
+
+type htmlRelease struct {
+	Release   string
+	Namespace string
+	Chart     string
+	HasDrift  bool
+	Resources []*htmlResource
+	InSync    int
+}
+
+type htmlResource struct {
+	Kind      string
+	Name      string
+	Namespace string
+	Drifted   string
+	State     string
+	Class     string
+	Changes   []*deviation.Change
+	Rows      []*htmlDiffRow
+}
+
+// htmlDiffRow is a row of the side-by-side diff, Hunk is set on the rows separating the hunks of the diff.
+type htmlDiffRow struct {
+	Hunk      string
+	Left      string
+	LeftType  string
+	Right     string
+	RightType string
+}
+
+// toHTML renders the drifts as a self-contained HTML report, with a summary and a collapsible section for every release
+// holding the side-by-side diffs of its resources that are not in sync. Resources could be filtered by namespace and kind.
+func (drift *Drift) toHTML(drifts []*deviation.DriftedRelease) error {
+	tmpl, err := template.New("report").Parse(htmlReportTemplate)
+	if err != nil {
+		return &errors.DriftError{Message: fmt.Sprintf("parsing html report template errored with '%v'", err)}
+	}
+
+	if err = tmpl.Execute(drift.writer, drift.htmlReport(drifts)); err != nil {
+		return &errors.DriftError{Message: fmt.Sprintf("rendering drifts as html errored with '%v'", err)}
+	}
+
+	return drift.flush()
+}
+
+func (drift *Drift) htmlReport(drifts []*deviation.DriftedRelease) *htmlReport {
+	report := &htmlReport{TimeSpent: drift.timeSpent, LeftLabel: "live", RightLabel: "desired"}
+	if len(drift.against) != 0 {
+		report.LeftLabel, report.RightLabel = drift.against, "release"
+	}
+
+	namespaces, kinds := make(map[string]struct{}), make(map[string]struct{})
+	allDeviations := make(deviation.Deviations, 0)
+
+	for _, release := range drifts {
+		htmlRelease := &htmlRelease{Release: release.Release, Namespace: release.Namespace, Chart: release.Chart, HasDrift: release.HasDrift}
+
+		for _, dvn := range release.Deviations {
+			report.Resources++
+
+			if !dvn.HasDrift && len(dvn.Status) == 0 {
+				htmlRelease.InSync++
+
+				continue
+			}
+
+			nameSpace := drift.setNameSpace(release, dvn)
+			namespaces[nameSpace], kinds[dvn.Kind] = struct{}{}, struct{}{}
+
+			class := dvn.Status
+			if len(class) == 0 {
+				class = "drifted"
+			}
+
+			htmlRelease.Resources = append(htmlRelease.Resources, &htmlResource{
+				Kind:      dvn.Kind,
+				Name:      dvn.Resource,
+				Namespace: nameSpace,
+				Drifted:   dvn.Drifted(),
+				State:     drift.describe(dvn),
+				Class:     class,
+				Changes:   dvn.Changes,
+				Rows:      sideBySide(dvn.Deviations),
+			})
+		}
+
+		if release.HasDrift {
+			report.Drifted++
+		}
+
+		allDeviations = append(allDeviations, release.Deviations...)
+		report.Releases = append(report.Releases, htmlRelease)
+	}
+
+	report.Drifts = allDeviations.Count()
+	report.Namespaces, report.Kinds = sortedKeys(namespaces), sortedKeys(kinds)
+
+	if counts := statusCounts(allDeviations); len(counts) != 0 {
+		report.Statuses = strings.Split(counts, ", ")
+	}
+
+	return report
+}
+
+// sideBySide lays out the unified diff side by side, the lines removed on the left and the ones added on the right.
+// Consecutive lines removed and added are paired, so that the lines changed are on the same row.
+func sideBySide(diff string) []*htmlDiffRow {
+	rows := make([]*htmlDiffRow, 0)
+	removed, added := make([]string, 0), make([]string, 0)
+
+	flush := func() {
+		for index := 0; index < len(removed) || index < len(added); index++ {
+			row := &htmlDiffRow{LeftType: htmlCellEmpty, RightType: htmlCellEmpty}
+
+			if index < len(removed) {
+				row.Left, row.LeftType = removed[index], htmlCellRemoved
+			}
+
+			if index < len(added) {
+				row.Right, row.RightType = added[index], htmlCellAdded
+			}
+
+			rows = append(rows, row)
+		}
+
+		removed, added = removed[:0], added[:0]
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff "), strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "), len(line) == 0:
+			continue
+		case strings.HasPrefix(line, "@@"):
+			flush()
+
+			rows = append(rows, &htmlDiffRow{Hunk: line})
+		case strings.HasPrefix(line, "-"):
+			removed = append(removed, line[1:])
+		case strings.HasPrefix(line, "+"):
+			added = append(added, line[1:])
+		default:
+			flush()
+
+			line = strings.TrimPrefix(line, " ")
+			rows = append(rows, &htmlDiffRow{Left: line, LeftType: htmlCellContext, Right: line, RightType: htmlCellContext})
+		}
+	}
+
+	flush()
+
+	return rows
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSideBySide(t *testing.T) {
+	diff := `diff -u -N /tmp/LIVE-1/apps.v1.Deployment.sample.sample /tmp/MERGED-1/apps.v1.Deployment.sample.sample
+--- /tmp/LIVE-1/apps.v1.Deployment.sample.sample
++++ /tmp/MERGED-1/apps.v1.Deployment.sample.sample
+@@ -6,7 +6,8 @@
+ spec:
+-  replicas: 2
++  replicas: 1
++  paused: true
+   selector:
+`
+
+	assert.Equal(t, []*htmlDiffRow{
+		{Hunk: "@@ -6,7 +6,8 @@"},
+		{Left: "spec:", LeftType: htmlCellContext, Right: "spec:", RightType: htmlCellContext},
+		{Left: "  replicas: 2", LeftType: htmlCellRemoved, Right: "  replicas: 1", RightType: htmlCellAdded},
+		{LeftType: htmlCellEmpty, Right: "  paused: true", RightType: htmlCellAdded},
+		{Left: "  selector:", LeftType: htmlCellContext, Right: "  selector:", RightType: htmlCellContext},
+	}, sideBySide(diff))
+
+	assert.Empty(t, sideBySide(""))
+}
+
+func TestRenderHTML(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	drift := Drift{OutputFormat: "html"}
+	drift.SetLogger("error")
+	drift.SetWriter(buffer)
+
+	releases := newReportedReleases()
+	releases[0].Deviations[0].Deviations = "@@ -1 +1 @@\n-  image: <nginx:1.0>\n+  image: nginx:1.1\n"
+
+	require.NoError(t, drift.Render(&Report{Releases: releases}))
+
+	html := buffer.String()
+	assert.True(t, strings.HasPrefix(html, "<!DOCTYPE html>"))
+	assert.Contains(t, html, `<tr><td>Releases drifted</td><td>1</td></tr>`)
+	assert.Contains(t, html, `<option value="sample">sample</option>`)
+	assert.Contains(t, html, `<option value="Deployment">Deployment</option>`)
+	assert.Contains(t, html, `<div class="resource" data-namespace="sample" data-kind="Deployment">`)
+	assert.Contains(t, html, `<td class="removed">  image: &lt;nginx:1.0&gt;</td><td class="added">  image: nginx:1.1</td>`)
+	assert.Contains(t, html, `<span class="badge orphaned">ORPHANED</span>`)
+	assert.Contains(t, html, `<p class="muted">1 resource(s) in sync</p>`)
+	assert.NotContains(t, html, `data-kind="Service"`, "resources in sync should not be listed")
+}
@@ -0,0 +1,175 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"github.com/nikhilsbhat/helm-drift/pkg/fields"
+	"github.com/thoas/go-funk"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultIgnoreFile is the file from the working directory from which the ignore rules are loaded, when no ignore file is specified.
+	DefaultIgnoreFile = ".helmdriftignore.yaml"
+
+	suppressedByIgnoreRule = "ignore-rule"
+)
+
+// IgnoreRules holds the rules to ignore drifts on specific fields of the resources.
+type IgnoreRules struct {
+	Rules []*IgnoreRule `json:"rules,omitempty" yaml:"rules,omitempty"`
+}
+
+// IgnoreRule selects the resources by kinds, names, namespaces and releases, and lists the fields to be ignored on them.
+// Selectors that are left empty match all resources.
+type IgnoreRule struct {
+	Kinds      []string `json:"kinds,omitempty" yaml:"kinds,omitempty"`
+	Names      []string `json:"names,omitempty" yaml:"names,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+	Releases   []string `json:"releases,omitempty" yaml:"releases,omitempty"`
+	Paths      []string `json:"paths,omitempty" yaml:"paths,omitempty"`
+}
+
+// SetIgnoreRules loads the ignore rules from the ignore file set, if not set the rules are loaded from DefaultIgnoreFile when it exists.
+func (drift *Drift) SetIgnoreRules() error {
+	ignoreFile := drift.IgnoreFile
+	if len(ignoreFile) == 0 {
+		if _, err := os.Stat(DefaultIgnoreFile); err != nil {
+			return nil
+		}
+
+		ignoreFile = DefaultIgnoreFile
+	}
+
+	drift.log.Debugf("loading ignore rules from '%s'", ignoreFile)
+
+	content, err := os.ReadFile(ignoreFile)
+	if err != nil {
+		return &errors.DriftError{Message: fmt.Sprintf("reading ignore file '%s' errored with '%v'", ignoreFile, err)}
+	}
+
+	var ignoreRules IgnoreRules
+	if err = yaml.UnmarshalStrict(content, &ignoreRules); err != nil {
+		return &errors.DriftError{Message: fmt.Sprintf("parsing ignore file '%s' errored with '%v'", ignoreFile, err)}
+	}
+
+	for index, rule := range ignoreRules.Rules {
+		if len(rule.Paths) == 0 {
+			return &errors.DriftError{Message: fmt.Sprintf("rule %d of ignore file '%s' has no paths to ignore", index, ignoreFile)}
+		}
+	}
+
+	drift.ignoreRules = ignoreRules.Rules
+
+	return nil
+}
+
+func (rule *IgnoreRule) matches(kind, name, namespace, release string) bool {
+	return selects(rule.Kinds, kind) && selects(rule.Names, name) &&
+		selects(rule.Namespaces, namespace) && selects(rule.Releases, release)
+}
+
+func selects(selectors []string, value string) bool {
+	return len(selectors) == 0 || funk.ContainsString(selectors, value)
+}
+
+// applyIgnoreRules removes the fields ignored by the matching rules from the manifest, so that they are left out while identifying drifts.
+// The fields removed are returned as suppressed changes, which would later be compared against the live object.
+func (drift *Drift) applyIgnoreRules(manifest string, template *deviation.Deviation, release, releaseNamespace string) (string, []*deviation.Change, error) {
+	if len(drift.ignoreRules) == 0 {
+		return manifest, nil, nil
+	}
+
+	nameSpace := template.NameSpace
+	if len(nameSpace) == 0 {
+		nameSpace = releaseNamespace
+	}
+
+	paths := make([]string, 0)
+
+	for _, rule := range drift.ignoreRules {
+		if rule.matches(template.Kind, template.Resource, nameSpace, release) {
+			paths = append(paths, rule.Paths...)
+		}
+	}
+
+	if len(paths) == 0 {
+		return manifest, nil, nil
+	}
+
+	object := make(map[string]any)
+	if err := yaml.Unmarshal([]byte(manifest), &object); err != nil {
+		return "", nil, err
+	}
+
+	suppressed := make([]*deviation.Change, 0)
+
+	for _, path := range paths {
+		for fieldPath, value := range fields.Remove(object, path) {
+			drift.log.Debugf("ignoring field '%s' of '%s' '%s' as per the ignore rules", fieldPath, template.Kind, template.Resource)
+
+			suppressed = append(suppressed, &deviation.Change{Path: fieldPath, Desired: value, SuppressedBy: suppressedByIgnoreRule})
+		}
+	}
+
+	if len(suppressed) == 0 {
+		return manifest, nil, nil
+	}
+
+	out, err := yaml.Marshal(object)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return string(out), suppressed, nil
+}
+
+// setSuppressedChanges compares the fields ignored against the live object, only the ones that have drifted are retained.
+func (drift *Drift) setSuppressedChanges(ctx context.Context, dvn *deviation.Deviation, nameSpace string, objects *resourceObjects) error {
+	desired, live, err := drift.liveObject(ctx, objects, dvn, nameSpace)
+	if err != nil {
+		return err
+	}
+
+	managedFields := managedFieldsOf(live)
+
+	if err = drift.normalize(live); err != nil {
+		return err
+	}
+
+	suppressed := make([]*deviation.Change, 0, len(dvn.Suppressed))
+
+	for _, change := range dvn.Suppressed {
+		var liveValue any
+
+		found := false
+		if live != nil {
+			liveValue, found = fields.Get(live.Object, change.Path)
+		}
+
+		switch {
+		case !found:
+			change.Type = deviation.ChangeAdded
+		case fields.Equal(change.Desired, liveValue):
+			continue
+		default:
+			change.Type = deviation.ChangeModified
+			change.Live = liveValue
+		}
+
+		suppressed = append(suppressed, change)
+	}
+
+	if desired.GetKind() == "Secret" {
+		maskSecretChanges(suppressed)
+	}
+
+	attributeChanges(managedFields, live, suppressed)
+	dvn.Suppressed = suppressed
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const configMapManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+data:
+  ca.crt: certificate
+  config: value
+`
+
+func TestSetIgnoreRules(t *testing.T) {
+	t.Run("loads rules from the ignore file", func(t *testing.T) {
+		ignoreFile := filepath.Join(t.TempDir(), "ignore.yaml")
+		require.NoError(t, os.WriteFile(ignoreFile, []byte(`
+rules:
+  - kinds: [ConfigMap]
+    names: [foo]
+    paths:
+      - data.ca.crt
+`), 0o600))
+
+		drift := Drift{IgnoreFile: ignoreFile}
+		drift.SetLogger("error")
+
+		require.NoError(t, drift.SetIgnoreRules())
+		assert.Equal(t, []*IgnoreRule{{Kinds: []string{"ConfigMap"}, Names: []string{"foo"}, Paths: []string{"data.ca.crt"}}}, drift.ignoreRules)
+	})
+
+	t.Run("errors on rules without paths", func(t *testing.T) {
+		ignoreFile := filepath.Join(t.TempDir(), "ignore.yaml")
+		require.NoError(t, os.WriteFile(ignoreFile, []byte("rules:\n  - kinds: [ConfigMap]\n"), 0o600))
+
+		drift := Drift{IgnoreFile: ignoreFile}
+		drift.SetLogger("error")
+
+		assert.Error(t, drift.SetIgnoreRules())
+	})
+
+	t.Run("errors on unknown fields", func(t *testing.T) {
+		ignoreFile := filepath.Join(t.TempDir(), "ignore.yaml")
+		require.NoError(t, os.WriteFile(ignoreFile, []byte("rules:\n  - kind: [ConfigMap]\n    paths: [data]\n"), 0o600))
+
+		drift := Drift{IgnoreFile: ignoreFile}
+		drift.SetLogger("error")
+
+		assert.Error(t, drift.SetIgnoreRules())
+	})
+
+	t.Run("no rules when default ignore file does not exist", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+
+		drift := Drift{}
+		drift.SetLogger("error")
+
+		require.NoError(t, drift.SetIgnoreRules())
+		assert.Empty(t, drift.ignoreRules)
+	})
+}
+
+func TestApplyIgnoreRules(t *testing.T) {
+	drift := Drift{ignoreRules: []*IgnoreRule{
+		{Kinds: []string{"ConfigMap"}, Names: []string{"foo"}, Paths: []string{"data.ca.crt"}},
+		{Kinds: []string{"ConfigMap"}, Namespaces: []string{"other"}, Paths: []string{"data.config"}},
+	}}
+	drift.SetLogger("error")
+
+	template := &deviation.Deviation{Kind: "ConfigMap", Resource: "foo"}
+
+	manifest, suppressed, err := drift.applyIgnoreRules(configMapManifest, template, "release", "sample")
+	require.NoError(t, err)
+
+	assert.NotContains(t, manifest, "ca.crt")
+	assert.Contains(t, manifest, "config: value")
+	assert.Equal(t, []*deviation.Change{
+		{Path: `data.ca\.crt`, Desired: "certificate", SuppressedBy: suppressedByIgnoreRule},
+	}, suppressed)
+
+	manifest, suppressed, err = drift.applyIgnoreRules(configMapManifest, &deviation.Deviation{Kind: "ConfigMap", Resource: "bar"}, "release", "sample")
+	require.NoError(t, err)
+
+	assert.Equal(t, configMapManifest, manifest)
+	assert.Empty(t, suppressed)
+}
+
+func TestIgnoreRuleMatches(t *testing.T) {
+	rule := IgnoreRule{Kinds: []string{"Deployment"}, Namespaces: []string{"sample"}}
+
+	assert.True(t, rule.matches("Deployment", "any", "sample", "release"))
+	assert.False(t, rule.matches("Deployment", "any", "other", "release"))
+	assert.False(t, rule.matches("StatefulSet", "any", "sample", "release"))
+}
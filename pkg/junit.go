@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+)
+
+const junitFailureDrift = "drift"
+
+// junitTestSuites is the root of the JUnit XML report, with a test suite for every release.
+type junitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Name     string            `xml:"name,attr"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Errors   int               `xml:"errors,attr"`
+	Time     float64           `xml:"time,attr"`
+	Suites   []*junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite holds a test case for every resource of the release.
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Errors    int              `xml:"errors,attr"`
+	TestCases []*junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase fails when the resource has drifted, and errors when its drifts could not be identified (ex: timed-out).
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// toJUnit renders the drifts as JUnit XML, with a test suite for every release and a test case for every resource from it.
+func (drift *Drift) toJUnit(drifts []*deviation.DriftedRelease) error {
+	out, err := xml.MarshalIndent(drift.junitReport(drifts), "", "  ")
+	if err != nil {
+		return &errors.DriftError{Message: fmt.Sprintf("rendering drifts as junit errored with '%v'", err)}
+	}
+
+	drift.write(xml.Header)
+	drift.write(addNewLine(string(out)))
+
+	return drift.flush()
+}
+
+func (drift *Drift) junitReport(drifts []*deviation.DriftedRelease) *junitTestSuites {
+	report := &junitTestSuites{Name: "helm-drift", Time: drift.timeSpent, Suites: make([]*junitTestSuite, 0, len(drifts))}
+
+	for _, release := range drifts {
+		suite := &junitTestSuite{Name: release.Release, TestCases: make([]*junitTestCase, 0, len(release.Deviations))}
+
+		for _, dvn := range release.Deviations {
+			testCase := &junitTestCase{
+				Name:      fmt.Sprintf("%s/%s", dvn.Kind, dvn.Resource),
+				ClassName: fmt.Sprintf("%s.%s", release.Namespace, release.Release),
+			}
+
+			switch {
+			case dvn.Status == deviation.StatusTimedOut:
+				testCase.Error = &junitProblem{Message: drift.describe(dvn), Type: dvn.Status}
+				suite.Errors++
+			case dvn.HasDrift:
+				problemType := dvn.Status
+				if len(problemType) == 0 {
+					problemType = junitFailureDrift
+				}
+
+				testCase.Failure = &junitProblem{Message: drift.describe(dvn), Type: problemType, Text: dvn.Deviations}
+				suite.Failures++
+			}
+
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+
+		suite.Tests = len(suite.TestCases)
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Suites = append(report.Suites, suite)
+	}
+
+	return report
+}
@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReportedReleases() []*deviation.DriftedRelease {
+	return []*deviation.DriftedRelease{
+		{
+			Release:   "sample",
+			Namespace: "sample",
+			HasDrift:  true,
+			Deviations: []*deviation.Deviation{
+				{
+					Kind: "Deployment", Resource: "sample", HasDrift: true, Deviations: "-  replicas: 2\n+  replicas: 1\n",
+					ManifestPath: "/tmp/sample.Deployment.sample.yaml", Source: "sample/templates/deployment.yaml",
+				},
+				{Kind: "Service", Resource: "sample"},
+				{Kind: "ConfigMap", Resource: "old", NameSpace: "sample", HasDrift: true, Status: deviation.StatusOrphaned},
+			},
+		},
+		{
+			Release:   "other",
+			Namespace: "other",
+			Deviations: []*deviation.Deviation{
+				{Kind: "Secret", Resource: "credentials", Status: deviation.StatusTimedOut},
+			},
+		},
+	}
+}
+
+func TestRenderJUnit(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	drift := Drift{OutputFormat: "junit"}
+	drift.SetLogger("error")
+	drift.SetWriter(buffer)
+
+	require.NoError(t, drift.Render(&Report{Releases: newReportedReleases(), TimeSpent: 1.5}))
+	assert.True(t, strings.HasPrefix(buffer.String(), xml.Header))
+
+	report := new(junitTestSuites)
+	require.NoError(t, xml.Unmarshal(buffer.Bytes(), report))
+
+	assert.Equal(t, 4, report.Tests)
+	assert.Equal(t, 2, report.Failures)
+	assert.Equal(t, 1, report.Errors)
+	assert.InDelta(t, 1.5, report.Time, 0)
+	require.Len(t, report.Suites, 2)
+
+	testCases := report.Suites[0].TestCases
+	require.Len(t, testCases, 3)
+
+	assert.Equal(t, "Deployment/sample", testCases[0].Name)
+	assert.Equal(t, "sample.sample", testCases[0].ClassName)
+	assert.Equal(t, &junitProblem{Message: "drifts identified", Type: "drift", Text: "-  replicas: 2\n+  replicas: 1\n"}, testCases[0].Failure)
+	assert.Nil(t, testCases[1].Failure)
+	assert.Equal(t, deviation.StatusOrphaned, testCases[2].Failure.Type)
+
+	assert.Nil(t, report.Suites[1].TestCases[0].Failure)
+	assert.Equal(t, &junitProblem{Message: "timed out identifying drifts", Type: deviation.StatusTimedOut}, report.Suites[1].TestCases[0].Error)
+}
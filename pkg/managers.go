@@ -0,0 +1,162 @@
+package pkg
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/fields"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Prefixes of the keys in the fields owned by the field managers (FieldsV1), for fields, associative list items by their keys,
+// set items by their value and list items by their index.
+const (
+	ownedFieldPrefix = "f:"
+	ownedKeyPrefix   = "k:"
+	ownedValuePrefix = "v:"
+	ownedIndexPrefix = "i:"
+)
+
+// managedFieldsOf returns the managedFields of the live object, they are to be read before the live object is normalized.
+func managedFieldsOf(live *unstructured.Unstructured) []metav1.ManagedFieldsEntry {
+	if live == nil {
+		return nil
+	}
+
+	return live.GetManagedFields()
+}
+
+// attributeChanges sets the field manager that last updated each field changed, along with the time of the update,
+// from the managedFields of the live object (ex: 'kubectl-edit', 'kubectl-client-side-apply' or the name of an operator).
+// Changes on the fields missing from the live object are not attributed, as none of the field managers own them.
+func attributeChanges(managedFields []metav1.ManagedFieldsEntry, live *unstructured.Unstructured, changes []*deviation.Change) {
+	if live == nil || len(managedFields) == 0 || len(changes) == 0 {
+		return
+	}
+
+	ownedFields := make([]map[string]any, len(managedFields))
+
+	for index, entry := range managedFields {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+
+		_ = json.Unmarshal(entry.FieldsV1.Raw, &ownedFields[index])
+	}
+
+	for _, change := range changes {
+		if change.Type == deviation.ChangeAdded {
+			continue
+		}
+
+		var latest *metav1.ManagedFieldsEntry
+
+		for index := range managedFields {
+			if !ownsField(ownedFields[index], live.Object, fields.Split(change.Path)) {
+				continue
+			}
+
+			if latest == nil || updatedAt(&managedFields[index]).After(updatedAt(latest)) {
+				latest = &managedFields[index]
+			}
+		}
+
+		if latest == nil {
+			continue
+		}
+
+		change.Manager = latest.Manager
+		if latest.Time != nil {
+			change.UpdatedAt = latest.Time.UTC().Format(time.RFC3339)
+		}
+	}
+}
+
+// ownsField walks the fields owned by a field manager along the path of the field, resolving the list items from the live object.
+// Fields under the ones owned atomically (ex: the items of an atomic list) are owned by the field manager as well.
+func ownsField(owned map[string]any, node any, segments []string) bool {
+	if owned == nil {
+		return false
+	}
+
+	for _, segment := range segments {
+		if len(owned) == 0 {
+			return true
+		}
+
+		var child any
+
+		switch typed := node.(type) {
+		case map[string]any:
+			child, node = owned[ownedFieldPrefix+segment], typed[segment]
+		case []any:
+			index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(segment, "["), "]"))
+			if err != nil || index < 0 || index >= len(typed) {
+				return false
+			}
+
+			child, node = ownedItem(owned, typed[index], index), typed[index]
+		default:
+			return false
+		}
+
+		next, isMap := child.(map[string]any)
+		if !isMap {
+			return false
+		}
+
+		owned = next
+	}
+
+	return true
+}
+
+// ownedItem returns the fields owned of the list item, matching it either by its keys, its value or its index.
+func ownedItem(owned map[string]any, item any, index int) any {
+	for key, child := range owned {
+		switch {
+		case strings.HasPrefix(key, ownedKeyPrefix):
+			itemKeys := make(map[string]any)
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(key, ownedKeyPrefix)), &itemKeys); err != nil {
+				continue
+			}
+
+			itemMap, isMap := item.(map[string]any)
+			if isMap && matchesKeys(itemMap, itemKeys) {
+				return child
+			}
+		case strings.HasPrefix(key, ownedValuePrefix):
+			var value any
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(key, ownedValuePrefix)), &value); err == nil && fields.Equal(value, item) {
+				return child
+			}
+		case key == ownedIndexPrefix+strconv.Itoa(index):
+			return child
+		}
+	}
+
+	return nil
+}
+
+func matchesKeys(item, keys map[string]any) bool {
+	for key, value := range keys {
+		if !fields.Equal(item[key], value) {
+			return false
+		}
+	}
+
+	return len(keys) != 0
+}
+
+// updatedAt returns the time the field manager last updated the object, it is zero when it is not known.
+func updatedAt(entry *metav1.ManagedFieldsEntry) time.Time {
+	if entry.Time == nil {
+		return time.Time{}
+	}
+
+	return entry.Time.Time
+}
@@ -0,0 +1,110 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newManagedDeployment(t *testing.T) *unstructured.Unstructured {
+	t.Helper()
+
+	live := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]any{
+			"name":        "sample",
+			"namespace":   "sample",
+			"annotations": map[string]any{"example.com/owner": "team"},
+		},
+		"spec": map[string]any{
+			"replicas": int64(3),
+			"template": map[string]any{
+				"spec": map[string]any{
+					"containers": []any{
+						map[string]any{"name": "sidecar", "image": "sidecar:v1"},
+						map[string]any{"name": "sample", "image": "sample:v2"},
+					},
+				},
+			},
+		},
+	}}
+
+	newEntry := func(manager string, updatedAt time.Time, owned string) metav1.ManagedFieldsEntry {
+		return metav1.ManagedFieldsEntry{
+			Manager:    manager,
+			Operation:  metav1.ManagedFieldsOperationUpdate,
+			Time:       &metav1.Time{Time: updatedAt},
+			FieldsType: "FieldsV1",
+			FieldsV1:   &metav1.FieldsV1{Raw: []byte(owned)},
+		}
+	}
+
+	installedAt := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+
+	live.SetManagedFields([]metav1.ManagedFieldsEntry{
+		newEntry("helm", installedAt, `{"f:metadata":{"f:annotations":{".":{},"f:example.com/owner":{}}},`+
+			`"f:spec":{"f:replicas":{},"f:template":{"f:spec":{"f:containers":{`+
+			`"k:{\"name\":\"sample\"}":{".":{},"f:image":{},"f:name":{}},"k:{\"name\":\"sidecar\"}":{".":{},"f:image":{},"f:name":{}}}}}}}`),
+		newEntry("kubectl-edit", installedAt.Add(time.Hour), `{"f:spec":{"f:replicas":{}}}`),
+		newEntry("kubectl-set", installedAt.Add(2*time.Hour), `{"f:spec":{"f:template":{"f:spec":{"f:containers":{`+
+			`"k:{\"name\":\"sample\"}":{"f:image":{}}}}}}}`),
+	})
+
+	return live
+}
+
+func TestAttributeChanges(t *testing.T) {
+	live := newManagedDeployment(t)
+
+	changes := []*deviation.Change{
+		{Path: "spec.replicas", Type: deviation.ChangeModified},
+		{Path: "spec.template.spec.containers[1].image", Type: deviation.ChangeModified},
+		{Path: "spec.template.spec.containers[0].image", Type: deviation.ChangeModified},
+		{Path: `metadata.annotations.example\.com/owner`, Type: deviation.ChangeRemoved},
+		{Path: "spec.paused", Type: deviation.ChangeAdded},
+		{Path: "spec.minReadySeconds", Type: deviation.ChangeModified},
+	}
+
+	attributeChanges(live.GetManagedFields(), live, changes)
+
+	expected := []struct{ manager, updatedAt string }{
+		{manager: "kubectl-edit", updatedAt: "2026-10-01T11:00:00Z"},
+		{manager: "kubectl-set", updatedAt: "2026-10-01T12:00:00Z"},
+		{manager: "helm", updatedAt: "2026-10-01T10:00:00Z"},
+		{manager: "helm", updatedAt: "2026-10-01T10:00:00Z"},
+		{},
+		{},
+	}
+
+	for index, change := range changes {
+		assert.Equal(t, expected[index].manager, change.Manager, change.Path)
+		assert.Equal(t, expected[index].updatedAt, change.UpdatedAt, change.Path)
+	}
+}
+
+func TestSetChangesAttributed(t *testing.T) {
+	desired := newManagedDeployment(t)
+	desired.SetManagedFields(nil)
+	require.NoError(t, unstructured.SetNestedField(desired.Object, int64(1), "spec", "replicas"))
+
+	drift := New(newFakeClusterOptions(newManagedDeployment(t))...)
+	drift.SetLogger("error")
+	drift.Normalize = []string{"clean"}
+	require.NoError(t, drift.SetNormalizers())
+
+	dvn := &deviation.Deviation{Kind: "Deployment", Resource: "sample", HasDrift: true, ManifestPath: writeManifest(t, desired.Object)}
+	require.NoError(t, drift.setChanges(t.Context(), dvn, "sample", new(resourceObjects)))
+
+	assert.Equal(t, []*deviation.Change{
+		{
+			Path: "spec.replicas", Type: deviation.ChangeModified, Desired: float64(1), Live: float64(3),
+			Manager: "kubectl-edit", UpdatedAt: "2026-10-01T11:00:00Z",
+		},
+	}, dvn.Changes)
+}
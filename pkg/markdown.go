@@ -0,0 +1,142 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+)
+
+const (
+	// markdownDiffLimit is the size of the diff of a resource, beyond which it is truncated.
+	markdownDiffLimit = 10000
+	// markdownReportLimit is the size of the report, beyond which the diffs of the rest of the resources are left out.
+	// It is kept below the size of comments allowed on pull/merge requests (65536 characters on GitHub).
+	markdownReportLimit = 60000
+)
+
+// toMarkdown renders the drifts as markdown to be posted on pull/merge requests, with a summary table of the releases
+// and a collapsible block for every resource that is not in sync, holding its diff.
+// Diffs larger than markdownDiffLimit are truncated, and once the report reaches markdownReportLimit the rest of the diffs are left out.
+func (drift *Drift) toMarkdown(drifts []*deviation.DriftedRelease) error {
+	releases := deviation.DriftedReleases(drifts)
+	allDeviations := make(deviation.Deviations, 0)
+
+	report := new(strings.Builder)
+	report.WriteString("## helm drift\n\n")
+	report.WriteString("| release | namespace | drifted | resources not in sync |\n")
+	report.WriteString("|---|---|---|---|\n")
+
+	for _, release := range drifts {
+		releaseDeviations := deviation.Deviations(release.Deviations)
+		report.WriteString(fmt.Sprintf("| %s | %s | %s | %d |\n", release.Release, release.Namespace, release.Drifted(), notInSync(releaseDeviations)))
+
+		allDeviations = append(allDeviations, release.Deviations...)
+	}
+
+	report.WriteString(fmt.Sprintf("\n**Status: %s**, total number of drifts found: %d", releases.Status(), allDeviations.Count()))
+
+	if counts := statusCounts(allDeviations); len(counts) != 0 {
+		report.WriteString(fmt.Sprintf(" (%s)", counts))
+	}
+
+	report.WriteString("\n\n")
+
+	var leftOut int
+
+	for _, release := range drifts {
+		for _, dvn := range release.Deviations {
+			if !dvn.HasDrift && len(dvn.Status) == 0 {
+				continue
+			}
+
+			block := drift.markdownDetails(release, dvn)
+			if report.Len()+len(block) > markdownReportLimit {
+				leftOut++
+
+				continue
+			}
+
+			report.WriteString(block)
+		}
+	}
+
+	if leftOut != 0 {
+		report.WriteString(fmt.Sprintf("> [!NOTE]\n> Diffs of %d more resource(s) were left out to keep the report within %d characters.\n",
+			leftOut, markdownReportLimit))
+	}
+
+	drift.write(report.String())
+
+	return drift.flush()
+}
+
+// markdownDetails renders the collapsible block of the resource, with its diff fenced as diff.
+func (drift *Drift) markdownDetails(release *deviation.DriftedRelease, dvn *deviation.Deviation) string {
+	block := new(strings.Builder)
+	block.WriteString(fmt.Sprintf("<details>\n<summary>%s: %s/%s (%s)</summary>\n\n", release.Release, dvn.Kind, dvn.Resource, drift.describe(dvn)))
+
+	if len(dvn.Deviations) == 0 {
+		block.WriteString(fmt.Sprintf("'%s' '%s' is %s.\n\n</details>\n\n", dvn.Kind, dvn.Resource, dvn.Drifted()))
+
+		return block.String()
+	}
+
+	diff, truncated := truncateDiff(dvn.Deviations, markdownDiffLimit)
+	fence := markdownFence(diff)
+
+	block.WriteString(fmt.Sprintf("%sdiff\n%s\n%s\n", fence, strings.TrimRight(diff, "\n"), fence))
+
+	if truncated {
+		block.WriteString(fmt.Sprintf("\n_Diff truncated, it is larger than %d characters._\n", markdownDiffLimit))
+	}
+
+	block.WriteString("\n</details>\n\n")
+
+	return block.String()
+}
+
+// truncateDiff cuts the diff down to the limit at the end of a line, it reports whether the diff was truncated.
+func truncateDiff(diff string, limit int) (string, bool) {
+	if len(diff) <= limit {
+		return diff, false
+	}
+
+	diff = diff[:limit]
+	if index := strings.LastIndex(diff, "\n"); index > 0 {
+		diff = diff[:index+1]
+	}
+
+	return diff, true
+}
+
+// markdownFence returns a code fence longer than any run of backticks in the content, so that the content cannot close it.
+func markdownFence(content string) string {
+	longest, current := 0, 0
+
+	for _, char := range content {
+		if char != '`' {
+			current = 0
+
+			continue
+		}
+
+		current++
+		longest = max(longest, current)
+	}
+
+	return strings.Repeat("`", max(3, longest+1)) //nolint:mnd
+}
+
+// notInSync returns the number of resources that have drifted or are in one of the other states (ex: missing).
+func notInSync(deviations deviation.Deviations) int {
+	var count int
+
+	for _, dvn := range deviations {
+		if dvn.HasDrift || len(dvn.Status) != 0 {
+			count++
+		}
+	}
+
+	return count
+}
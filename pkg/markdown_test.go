@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	t.Run("should render the summary table and the diffs of the resources not in sync", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+		drift := Drift{OutputFormat: "markdown"}
+		drift.SetLogger("error")
+		drift.SetWriter(buffer)
+
+		require.NoError(t, drift.Render(&Report{Releases: newReportedReleases()}))
+
+		markdown := buffer.String()
+		assert.True(t, strings.HasPrefix(markdown, "## helm drift\n\n| release | namespace | drifted | resources not in sync |\n|---|---|---|---|\n"))
+		assert.Contains(t, markdown, "| sample | sample | YES | 2 |\n| other | other | NO | 1 |\n")
+		assert.Contains(t, markdown, "**Status: FAILED**, total number of drifts found: 2 (orphaned: 1, timed-out: 1)")
+		assert.Contains(t, markdown, "<details>\n<summary>sample: Deployment/sample (drifts identified)</summary>\n\n"+
+			"```diff\n-  replicas: 2\n+  replicas: 1\n```\n\n</details>\n")
+		assert.Contains(t, markdown, "<summary>sample: ConfigMap/old (orphaned, not part of the release anymore)</summary>\n\n'ConfigMap' 'old' is ORPHANED.\n")
+		assert.NotContains(t, markdown, "Service/sample")
+	})
+
+	t.Run("should truncate huge diffs and leave out the diffs beyond the size of the report", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+		drift := Drift{OutputFormat: "markdown"}
+		drift.SetLogger("error")
+		drift.SetWriter(buffer)
+
+		hugeDiff := strings.Repeat("+  key: value\n", markdownDiffLimit)
+		deviations := make([]*deviation.Deviation, 0)
+
+		for range 10 {
+			deviations = append(deviations, &deviation.Deviation{Kind: "ConfigMap", Resource: "huge", HasDrift: true, Deviations: hugeDiff})
+		}
+
+		require.NoError(t, drift.Render(&Report{Releases: []*deviation.DriftedRelease{{Release: "sample", HasDrift: true, Deviations: deviations}}}))
+
+		markdown := buffer.String()
+		assert.LessOrEqual(t, len(markdown), markdownReportLimit+200)
+		assert.Contains(t, markdown, "_Diff truncated, it is larger than 10000 characters._")
+		assert.Contains(t, markdown, "Diffs of 5 more resource(s) were left out to keep the report within 60000 characters.")
+	})
+}
+
+func TestMarkdownFence(t *testing.T) {
+	assert.Equal(t, "```", markdownFence("+  key: value"))
+	assert.Equal(t, "````", markdownFence("+  script: ```echo```"))
+}
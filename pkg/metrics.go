@@ -0,0 +1,147 @@
+package pkg
+
+import (
+	"time"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "helm_drift"
+
+// driftMetrics holds the prometheus metrics exposed by 'helm drift serve', they reflect the latest scan of all releases.
+type driftMetrics struct {
+	registry         *prometheus.Registry
+	releasesScanned  prometheus.Gauge
+	releasesDrifted  prometheus.Gauge
+	releaseDrifted   *prometheus.GaugeVec
+	resourcesDrifted *prometheus.GaugeVec
+	resourcesMissing *prometheus.GaugeVec
+	scanDuration     prometheus.Histogram
+	lastScan         prometheus.Gauge
+	scans            prometheus.Counter
+	scanErrors       prometheus.Counter
+}
+
+func newDriftMetrics() *driftMetrics {
+	metrics := &driftMetrics{
+		registry: prometheus.NewRegistry(),
+		releasesScanned: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "releases_scanned",
+			Help:      "Number of helm releases scanned in the latest scan, including the ones whose drifts could not be identified.",
+		}),
+		releasesDrifted: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "releases_drifted",
+			Help:      "Number of helm releases that have drifted, as per the latest scan.",
+		}),
+		releaseDrifted: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "release_drifted",
+			Help:      "Whether the helm release has drifted (1) or not (0), as per the latest scan.",
+		}, []string{"release", "namespace"}),
+		resourcesDrifted: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "resources_drifted",
+			Help:      "Number of drifted resources by kind and namespace, as per the latest scan.",
+		}, []string{"kind", "namespace"}),
+		resourcesMissing: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "resources_missing",
+			Help:      "Number of resources rendered from the releases but missing from the cluster by kind and namespace, as per the latest scan.",
+		}, []string{"kind", "namespace"}),
+		scanDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "scan_duration_seconds",
+			Help:      "Time taken to identify drifts from all the helm releases.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12), //nolint:mnd
+		}),
+		lastScan: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "last_scan_timestamp_seconds",
+			Help:      "Unix time at which the latest scan completed.",
+		}),
+		scans: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "scans_total",
+			Help:      "Number of scans run to identify drifts from all the helm releases.",
+		}),
+		scanErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "scan_errors_total",
+			Help:      "Number of errors encountered while identifying drifts, a release that could not be scanned counts as one error.",
+		}),
+	}
+
+	metrics.registry.MustRegister(
+		metrics.releasesScanned,
+		metrics.releasesDrifted,
+		metrics.releaseDrifted,
+		metrics.resourcesDrifted,
+		metrics.resourcesMissing,
+		metrics.scanDuration,
+		metrics.lastScan,
+		metrics.scans,
+		metrics.scanErrors,
+	)
+
+	return metrics
+}
+
+// record updates the metrics with the results of a scan, the results of the previous scan are discarded
+// so that the releases and resources that no longer exist are not reported.
+// Releases scanned are all the releases selected, including the ones whose drifts could not be identified.
+func (metrics *driftMetrics) record(driftedReleases []*deviation.DriftedRelease, scanned, scanErrors int, duration time.Duration) {
+	metrics.releaseDrifted.Reset()
+	metrics.resourcesDrifted.Reset()
+	metrics.resourcesMissing.Reset()
+
+	drifted := 0
+
+	for _, driftedRelease := range driftedReleases {
+		metrics.releaseDrifted.WithLabelValues(driftedRelease.Release, driftedRelease.Namespace).Set(boolToFloat(driftedRelease.HasDrift))
+
+		if driftedRelease.HasDrift {
+			drifted++
+		}
+
+		for _, dvn := range driftedRelease.Deviations {
+			if dvn == nil || !dvn.HasDrift {
+				continue
+			}
+
+			nameSpace := dvn.NameSpace
+			if len(nameSpace) == 0 {
+				nameSpace = driftedRelease.Namespace
+			}
+
+			metrics.resourcesDrifted.WithLabelValues(dvn.Kind, nameSpace).Inc()
+
+			if dvn.Status == deviation.StatusMissing {
+				metrics.resourcesMissing.WithLabelValues(dvn.Kind, nameSpace).Inc()
+			}
+		}
+	}
+
+	metrics.releasesScanned.Set(float64(scanned))
+	metrics.releasesDrifted.Set(float64(drifted))
+	metrics.scanDuration.Observe(duration.Seconds())
+	metrics.lastScan.SetToCurrentTime()
+	metrics.scans.Inc()
+	metrics.scanErrors.Add(float64(scanErrors))
+}
+
+// recordFailure counts a scan that could not identify drifts from any of the releases, metrics of the previous scan are left as is.
+func (metrics *driftMetrics) recordFailure() {
+	metrics.scans.Inc()
+	metrics.scanErrors.Inc()
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
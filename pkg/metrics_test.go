@@ -0,0 +1,104 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriftMetrics(t *testing.T) {
+	driftedReleases := []*deviation.DriftedRelease{
+		{
+			Release:   "prometheus",
+			Namespace: "monitoring",
+			HasDrift:  true,
+			Deviations: []*deviation.Deviation{
+				{Kind: "Deployment", Resource: "prometheus", HasDrift: true},
+				{Kind: "ConfigMap", Resource: "prometheus", HasDrift: true},
+				{Kind: "ConfigMap", Resource: "rules", NameSpace: "alerting", HasDrift: true, Status: deviation.StatusMissing},
+				{Kind: "Service", Resource: "prometheus"},
+			},
+		},
+		{
+			Release:    "grafana",
+			Namespace:  "monitoring",
+			Deviations: []*deviation.Deviation{{Kind: "Deployment", Resource: "grafana"}},
+		},
+	}
+
+	t.Run("should record the results of the scan", func(t *testing.T) {
+		metrics := newDriftMetrics()
+		metrics.record(driftedReleases, 3, 1, 2*time.Second)
+
+		assert.InDelta(t, 3, testutil.ToFloat64(metrics.releasesScanned), 0)
+		assert.InDelta(t, 1, testutil.ToFloat64(metrics.releasesDrifted), 0)
+		assert.InDelta(t, 1, testutil.ToFloat64(metrics.releaseDrifted.WithLabelValues("prometheus", "monitoring")), 0)
+		assert.InDelta(t, 0, testutil.ToFloat64(metrics.releaseDrifted.WithLabelValues("grafana", "monitoring")), 0)
+		assert.InDelta(t, 1, testutil.ToFloat64(metrics.resourcesDrifted.WithLabelValues("Deployment", "monitoring")), 0)
+		assert.InDelta(t, 1, testutil.ToFloat64(metrics.resourcesDrifted.WithLabelValues("ConfigMap", "monitoring")), 0)
+		assert.InDelta(t, 1, testutil.ToFloat64(metrics.resourcesDrifted.WithLabelValues("ConfigMap", "alerting")), 0)
+		assert.InDelta(t, 1, testutil.ToFloat64(metrics.resourcesMissing.WithLabelValues("ConfigMap", "alerting")), 0)
+		assert.Equal(t, 1, testutil.CollectAndCount(metrics.resourcesMissing))
+		assert.InDelta(t, 1, testutil.ToFloat64(metrics.scanErrors), 0)
+		assert.InDelta(t, 1, testutil.ToFloat64(metrics.scans), 0)
+	})
+
+	t.Run("should discard the results of the previous scan", func(t *testing.T) {
+		metrics := newDriftMetrics()
+		metrics.record(driftedReleases, 2, 0, time.Second)
+		metrics.record(driftedReleases[1:], 1, 0, time.Second)
+
+		assert.InDelta(t, 1, testutil.ToFloat64(metrics.releasesScanned), 0)
+		assert.InDelta(t, 0, testutil.ToFloat64(metrics.releasesDrifted), 0)
+		assert.Equal(t, 0, testutil.CollectAndCount(metrics.resourcesDrifted))
+		assert.Equal(t, 1, testutil.CollectAndCount(metrics.releaseDrifted))
+		assert.InDelta(t, 2, testutil.ToFloat64(metrics.scans), 0)
+	})
+
+	t.Run("should count failed scans as errors", func(t *testing.T) {
+		metrics := newDriftMetrics()
+		metrics.recordFailure()
+
+		expected := `
+# HELP helm_drift_scan_errors_total Number of errors encountered while identifying drifts, a release that could not be scanned counts as one error.
+# TYPE helm_drift_scan_errors_total counter
+helm_drift_scan_errors_total 1
+`
+		require.NoError(t, testutil.GatherAndCompare(metrics.registry, strings.NewReader(expected), "helm_drift_scan_errors_total"))
+	})
+}
+
+func TestServeInvalidInterval(t *testing.T) {
+	drift := Drift{}
+	drift.SetLogger("error")
+
+	err := drift.Serve(t.Context(), DefaultListenAddress, DefaultMetricsPath, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "scan interval should be greater than zero")
+}
+
+func TestServeAddressInUse(t *testing.T) {
+	listener, err := (&net.ListenConfig{}).Listen(t.Context(), "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer listener.Close()
+
+	drift := Drift{}
+	drift.SetLogger("error")
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	err = drift.Serve(ctx, listener.Addr().String(), DefaultMetricsPath, time.Minute)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("listening on '%s' errored with", listener.Addr()))
+	assert.NoError(t, ctx.Err(), "the server should fail before scanning the releases")
+}
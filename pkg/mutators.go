@@ -0,0 +1,422 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"github.com/nikhilsbhat/helm-drift/pkg/fields"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// MutatorHPA owns the replicas of the workloads scaled by HorizontalPodAutoscalers.
+	MutatorHPA = "hpa"
+	// MutatorKEDA owns the replicas of the workloads scaled by KEDA ScaledObjects.
+	MutatorKEDA = "keda"
+	// MutatorArgoRollouts owns the replicas of the Deployments referred by Argo Rollouts,
+	// and the pod template hash Argo Rollouts adds to the selectors of their Services.
+	MutatorArgoRollouts = "argo-rollouts"
+	// MutatorCertManager owns the CA bundles cert-manager injects into the resources annotated for CA injection.
+	MutatorCertManager = "cert-manager"
+	// MutatorVPA owns the resource requests and limits of the workloads updated by VerticalPodAutoscalers.
+	MutatorVPA = "vpa"
+	// MutatorIstio owns the sidecars, volumes, labels and annotations injected by Istio.
+	MutatorIstio = "istio"
+)
+
+var (
+	kedaScaledObjectResource = schema.GroupVersionResource{Group: "keda.sh", Version: "v1alpha1", Resource: "scaledobjects"}
+	argoRolloutResource      = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
+	vpaResource              = schema.GroupVersionResource{Group: "autoscaling.k8s.io", Version: "v1", Resource: "verticalpodautoscalers"}
+
+	certManagerInjectAnnotations = []string{
+		"cert-manager.io/inject-ca-from", "cert-manager.io/inject-ca-from-secret", "cert-manager.io/inject-apiserver-ca",
+	}
+	istioInjectedVolumes = []string{"workload-socket", "credential-socket", "workload-certs"}
+)
+
+// Mutator is a controller that changes the fields of the resources it targets once they are applied, ex: HPA scaling the replicas.
+// Drifts confined to the fields owned by the enabled mutators are suppressed, and are reported as suppressed by the mutator.
+type Mutator struct {
+	// Name of the mutator, with which it is enabled and the changes it owns are reported as suppressed by.
+	Name string
+	// Paths of the fields owned by the mutator, the fields under them are owned as well.
+	// Items of a list could be matched with '[*]' and dots in the keys are to be escaped, ex: 'metadata.annotations.sidecar\.istio\.io/status'.
+	Paths []string
+	// Owns is an optional check for the changes on the fields not under Paths, that are still made by the mutator.
+	Owns func(change *deviation.Change) bool
+	// Targets returns true when the resource is mutated by the mutator, the mutator targets every resource when it is not set.
+	Targets func(ctx context.Context, drift *Drift, dvn *deviation.Deviation, nameSpace string) (bool, error)
+}
+
+// KnownMutators returns the mutators helm drift knows of, that could be enabled with IgnoreMutators.
+func KnownMutators() []*Mutator {
+	return []*Mutator{
+		{
+			Name:  MutatorHPA,
+			Paths: []string{"spec.replicas"},
+			Targets: func(ctx context.Context, drift *Drift, dvn *deviation.Deviation, nameSpace string) (bool, error) {
+				return drift.IsManagedByHPA(ctx, dvn.Resource, dvn.Kind, nameSpace)
+			},
+		},
+		{
+			Name:    MutatorKEDA,
+			Paths:   []string{"spec.replicas"},
+			Targets: clusterTargets(MutatorKEDA, kedaTargets),
+		},
+		{
+			Name:    MutatorArgoRollouts,
+			Paths:   []string{"spec.replicas", "spec.selector.rollouts-pod-template-hash"},
+			Targets: clusterTargets(MutatorArgoRollouts, argoRolloutsTargets),
+		},
+		{
+			Name: MutatorVPA,
+			Paths: []string{
+				"spec.template.spec.containers[*].resources.requests", "spec.template.spec.containers[*].resources.limits",
+				"spec.template.spec.initContainers[*].resources.requests", "spec.template.spec.initContainers[*].resources.limits",
+				"spec.jobTemplate.spec.template.spec.containers[*].resources.requests",
+				"spec.jobTemplate.spec.template.spec.containers[*].resources.limits",
+			},
+			Targets: clusterTargets(MutatorVPA, vpaTargets),
+		},
+		{
+			Name: MutatorCertManager,
+			Paths: []string{
+				"webhooks[*].clientConfig.caBundle", "spec.conversion.webhook.clientConfig.caBundle", "spec.caBundle",
+			},
+			Targets: injectsCA,
+		},
+		{
+			Name: MutatorIstio,
+			Paths: []string{
+				`metadata.annotations.sidecar\.istio\.io/status`,
+				`metadata.annotations.kubectl\.kubernetes\.io/default-container`,
+				`metadata.annotations.kubectl\.kubernetes\.io/default-logs-container`,
+				`metadata.labels.security\.istio\.io/tlsMode`,
+				`metadata.labels.service\.istio\.io/canonical-name`,
+				`metadata.labels.service\.istio\.io/canonical-revision`,
+			},
+			Owns: istioInjected,
+		},
+	}
+}
+
+// WithMutators adds mutators to the ones enabled with IgnoreMutators, drifts confined to the fields they own are suppressed as well.
+func WithMutators(mutators ...*Mutator) Option {
+	return func(drift *Drift) {
+		drift.customMutators = append(drift.customMutators, mutators...)
+	}
+}
+
+// SetMutators sets the mutators enabled with IgnoreMutators, HPA and VPA are enabled with IgnoreHPAChanges and IgnoreVPAChanges as well.
+func (drift *Drift) SetMutators() error {
+	enabled := slices.Clone(drift.IgnoreMutators)
+	if drift.IgnoreHPAChanges {
+		enabled = append(enabled, MutatorHPA)
+	}
+
+	if drift.IgnoreVPAChanges {
+		enabled = append(enabled, MutatorVPA)
+	}
+
+	mutators := make([]*Mutator, 0, len(enabled))
+
+	for _, name := range enabled {
+		mutator := knownMutator(strings.TrimSpace(name))
+		if mutator == nil {
+			return &errors.DriftError{
+				Message: fmt.Sprintf("helm drift does not support mutator '%s', it should be one of hpa|keda|argo-rollouts|vpa|cert-manager|istio", name),
+			}
+		}
+
+		if !slices.ContainsFunc(mutators, func(added *Mutator) bool { return added.Name == mutator.Name }) {
+			mutators = append(mutators, mutator)
+		}
+	}
+
+	drift.mutators = mutators
+
+	return nil
+}
+
+// knownMutator returns the mutator helm drift knows of by its name, it is nil when there is none.
+func knownMutator(name string) *Mutator {
+	knownMutators := KnownMutators()
+
+	index := slices.IndexFunc(knownMutators, func(mutator *Mutator) bool {
+		return mutator.Name == name
+	})
+	if index == -1 {
+		return nil
+	}
+
+	return knownMutators[index]
+}
+
+// suppressMutations moves the changes of the drifted resource on the fields owned by the mutators targeting it to suppressed.
+// The resource is no longer considered drifted when all of its changes are suppressed, whereas a drifted resource
+// with no changes identified is left drifted, as what drifted on it is not known.
+func (drift *Drift) suppressMutations(ctx context.Context, dvn *deviation.Deviation, nameSpace string) error {
+	if !dvn.HasDrift || len(dvn.Status) != 0 || len(dvn.Changes) == 0 {
+		return nil
+	}
+
+	mutators, err := drift.mutatorsTargeting(ctx, slices.Concat(drift.mutators, drift.customMutators), dvn, nameSpace)
+	if err != nil || len(mutators) == 0 {
+		return err
+	}
+
+	changes := make([]*deviation.Change, 0, len(dvn.Changes))
+
+	for _, change := range dvn.Changes {
+		index := slices.IndexFunc(mutators, func(mutator *Mutator) bool {
+			return mutator.owns(change)
+		})
+		if index == -1 {
+			changes = append(changes, change)
+
+			continue
+		}
+
+		drift.log.Debugf("ignoring change on field '%s' of '%s' '%s' as it is owned by '%s'",
+			change.Path, dvn.Kind, dvn.Resource, mutators[index].Name)
+
+		change.SuppressedBy = mutators[index].Name
+		dvn.Suppressed = append(dvn.Suppressed, change)
+	}
+
+	dvn.Changes = changes
+
+	if len(changes) == 0 {
+		dvn.HasDrift, dvn.Deviations = false, ""
+	}
+
+	return nil
+}
+
+// mutatorsTargeting returns the mutators among the ones passed that mutate the resource.
+func (drift *Drift) mutatorsTargeting(ctx context.Context, mutators []*Mutator, dvn *deviation.Deviation, nameSpace string) ([]*Mutator, error) {
+	targeting := make([]*Mutator, 0, len(mutators))
+
+	for _, mutator := range mutators {
+		if mutator.Targets != nil {
+			targets, err := mutator.Targets(ctx, drift, dvn, nameSpace)
+			if err != nil {
+				return nil, &errors.DriftError{
+					Message: fmt.Sprintf("identifying resources mutated by '%s' errored with '%v'", mutator.Name, err),
+				}
+			}
+
+			if !targets {
+				continue
+			}
+		}
+
+		drift.log.Debugf("the '%s' '%s' is mutated by '%s' hence the drifts on the fields it owns would be suppressed",
+			dvn.Kind, dvn.Resource, mutator.Name)
+
+		targeting = append(targeting, mutator)
+	}
+
+	return targeting, nil
+}
+
+// owns returns true when the field changed is owned by the mutator.
+func (mutator *Mutator) owns(change *deviation.Change) bool {
+	for _, path := range mutator.Paths {
+		if fields.Under(change.Path, path) {
+			return true
+		}
+	}
+
+	return mutator.Owns != nil && mutator.Owns(change)
+}
+
+// clusterTargets returns the Targets of a mutator whose targets are listed from the cluster,
+// the targets are listed once per namespace and cached.
+func clusterTargets(
+	name string, list func(ctx context.Context, drift *Drift, nameSpace string) (map[string]struct{}, error),
+) func(ctx context.Context, drift *Drift, dvn *deviation.Deviation, nameSpace string) (bool, error) {
+	return func(ctx context.Context, drift *Drift, dvn *deviation.Deviation, nameSpace string) (bool, error) {
+		targets, err := drift.cachedTargets(ctx, name, nameSpace, list)
+		if err != nil {
+			return false, err
+		}
+
+		_, found := targets[targetKey(dvn.Resource, dvn.Kind)]
+
+		return found, nil
+	}
+}
+
+func (drift *Drift) cachedTargets(
+	ctx context.Context, name, nameSpace string, list func(ctx context.Context, drift *Drift, nameSpace string) (map[string]struct{}, error),
+) (map[string]struct{}, error) {
+	cacheKey := name + "/" + nameSpace
+
+	drift.targetsCacheMu.RLock()
+
+	if targets, ok := drift.targetsCache[cacheKey]; ok {
+		drift.targetsCacheMu.RUnlock()
+
+		return targets, nil
+	}
+
+	drift.targetsCacheMu.RUnlock()
+
+	targets, err := list(ctx, drift, nameSpace)
+	if err != nil {
+		return nil, err
+	}
+
+	drift.targetsCacheMu.Lock()
+	if drift.targetsCache == nil {
+		drift.targetsCache = make(map[string]map[string]struct{})
+	}
+
+	drift.targetsCache[cacheKey] = targets
+	drift.targetsCacheMu.Unlock()
+
+	return targets, nil
+}
+
+// listCustomResources lists the custom resources in the namespace, none are listed when their CRD is not installed on the cluster.
+func (drift *Drift) listCustomResources(
+	ctx context.Context, resource schema.GroupVersionResource, nameSpace string,
+) ([]unstructured.Unstructured, error) {
+	dynamicClient, err := drift.getDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := dynamicClient.Resource(resource).Namespace(nameSpace).List(ctx, metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		drift.log.Debugf("'%s' are not served by the cluster, hence none of the resources are mutated by them", resource.GroupResource())
+
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Items, nil
+}
+
+func kedaTargets(ctx context.Context, drift *Drift, nameSpace string) (map[string]struct{}, error) {
+	scaledObjects, err := drift.listCustomResources(ctx, kedaScaledObjectResource, nameSpace)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]struct{}, len(scaledObjects))
+
+	for _, scaledObject := range scaledObjects {
+		name, _, _ := unstructured.NestedString(scaledObject.Object, "spec", "scaleTargetRef", "name")
+
+		kind, _, _ := unstructured.NestedString(scaledObject.Object, "spec", "scaleTargetRef", "kind")
+		if len(kind) == 0 {
+			kind = "Deployment"
+		}
+
+		targets[targetKey(name, kind)] = struct{}{}
+	}
+
+	return targets, nil
+}
+
+func argoRolloutsTargets(ctx context.Context, drift *Drift, nameSpace string) (map[string]struct{}, error) {
+	rollouts, err := drift.listCustomResources(ctx, argoRolloutResource, nameSpace)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]struct{})
+
+	for _, rollout := range rollouts {
+		if name, found, _ := unstructured.NestedString(rollout.Object, "spec", "workloadRef", "name"); found {
+			kind, _, _ := unstructured.NestedString(rollout.Object, "spec", "workloadRef", "kind")
+			targets[targetKey(name, kind)] = struct{}{}
+		}
+
+		for _, service := range [][]string{
+			{"canary", "canaryService"}, {"canary", "stableService"}, {"blueGreen", "activeService"}, {"blueGreen", "previewService"},
+		} {
+			if name, found, _ := unstructured.NestedString(rollout.Object, "spec", "strategy", service[0], service[1]); found {
+				targets[targetKey(name, "Service")] = struct{}{}
+			}
+		}
+	}
+
+	return targets, nil
+}
+
+// vpaTargets lists the targets of the VerticalPodAutoscalers that update the resources of the pods, ones in mode 'Off' only recommend.
+func vpaTargets(ctx context.Context, drift *Drift, nameSpace string) (map[string]struct{}, error) {
+	verticalPodAutoscalers, err := drift.listCustomResources(ctx, vpaResource, nameSpace)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]struct{}, len(verticalPodAutoscalers))
+
+	for _, verticalPodAutoscaler := range verticalPodAutoscalers {
+		if updateMode, _, _ := unstructured.NestedString(verticalPodAutoscaler.Object, "spec", "updatePolicy", "updateMode"); updateMode == "Off" {
+			continue
+		}
+
+		name, _, _ := unstructured.NestedString(verticalPodAutoscaler.Object, "spec", "targetRef", "name")
+		kind, _, _ := unstructured.NestedString(verticalPodAutoscaler.Object, "spec", "targetRef", "kind")
+		targets[targetKey(name, kind)] = struct{}{}
+	}
+
+	return targets, nil
+}
+
+// injectsCA returns true when the manifest of the resource is annotated for cert-manager to inject the CA bundle into it.
+func injectsCA(_ context.Context, _ *Drift, dvn *deviation.Deviation, _ string) (bool, error) {
+	if len(dvn.ManifestPath) == 0 {
+		return false, nil
+	}
+
+	desired, err := readManifest(dvn.ManifestPath)
+	if err != nil {
+		return false, err
+	}
+
+	annotations := desired.GetAnnotations()
+
+	return slices.ContainsFunc(certManagerInjectAnnotations, func(annotation string) bool {
+		_, found := annotations[annotation]
+
+		return found
+	}), nil
+}
+
+// istioInjected returns true when the change is on a container or volume injected by Istio, which are not in the manifest.
+func istioInjected(change *deviation.Change) bool {
+	if change.Type != deviation.ChangeRemoved {
+		return false
+	}
+
+	segments := fields.Split(change.Path)
+	if len(segments) < 2 || !slices.Contains([]string{"containers", "initContainers", "volumes"}, segments[len(segments)-2]) {
+		return false
+	}
+
+	item, isMap := change.Live.(map[string]any)
+	if !isMap {
+		return false
+	}
+
+	name, _ := item["name"].(string)
+
+	return strings.HasPrefix(name, "istio-") || strings.HasPrefix(name, "istiod-") || slices.Contains(istioInjectedVolumes, name)
+}
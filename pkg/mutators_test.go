@@ -0,0 +1,399 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/internal/testutil"
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+// newMutatorsDrift returns Drift with the mutators passed enabled, talking to a fake cluster with the HPAs and the custom resources passed.
+func newMutatorsDrift(
+	t *testing.T, mutators []string, hpas []runtime.Object, objects ...runtime.Object,
+) (*Drift, *dynamicFake.FakeDynamicClient) {
+	t.Helper()
+
+	dynamicClient := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testutil.DeploymentResource: "DeploymentList",
+			kedaScaledObjectResource:    "ScaledObjectList",
+			argoRolloutResource:         "RolloutList",
+			vpaResource:                 "VerticalPodAutoscalerList",
+		}, objects...)
+
+	drift := New(append([]Option{WithKubeClient(kubeFake.NewClientset(hpas...)), WithDynamicClient(dynamicClient)},
+		newFakeClusterOptions()...)...)
+	drift.SetLogger("error")
+	drift.IgnoreMutators = mutators
+	require.NoError(t, drift.SetMutators())
+
+	return drift, dynamicClient
+}
+
+// newReplicasDeviation returns the deviation of Deployment 'sample' whose replicas have drifted.
+func newReplicasDeviation() *deviation.Deviation {
+	return &deviation.Deviation{
+		Kind:       "Deployment",
+		Resource:   "sample",
+		HasDrift:   true,
+		Deviations: "-  replicas: 3\n+  replicas: 1\n",
+		Changes: []*deviation.Change{
+			{Path: "spec.replicas", Type: deviation.ChangeModified, Desired: float64(1), Live: float64(3)},
+		},
+	}
+}
+
+func TestSetMutators(t *testing.T) {
+	tests := []struct {
+		name             string
+		mutators         []string
+		ignoreHPAChanges bool
+		ignoreVPAChanges bool
+		expected         []string
+		err              string
+	}{
+		{name: "should enable no mutators when none are selected", expected: []string{}},
+		{name: "should enable the mutators selected", mutators: []string{"istio", " keda"}, expected: []string{"istio", "keda"}},
+		{name: "should enable hpa with ignore hpa changes", mutators: []string{"hpa"}, ignoreHPAChanges: true, expected: []string{"hpa"}},
+		{name: "should enable vpa with ignore vpa changes", ignoreVPAChanges: true, expected: []string{"vpa"}},
+		{name: "should error on unsupported mutators", mutators: []string{"linkerd"}, err: "does not support mutator 'linkerd'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			drift := &Drift{IgnoreMutators: test.mutators, IgnoreHPAChanges: test.ignoreHPAChanges, IgnoreVPAChanges: test.ignoreVPAChanges}
+
+			err := drift.SetMutators()
+			if len(test.err) != 0 {
+				require.ErrorContains(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			names := make([]string, 0, len(drift.mutators))
+			for _, mutator := range drift.mutators {
+				names = append(names, mutator.Name)
+			}
+
+			assert.Equal(t, test.expected, names)
+		})
+	}
+}
+
+func TestSuppressMutations(t *testing.T) {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "sample"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "sample"},
+		},
+	}
+
+	scaledObject := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "keda.sh/v1alpha1",
+		"kind":       "ScaledObject",
+		"metadata":   map[string]any{"name": "sample", "namespace": "sample"},
+		"spec":       map[string]any{"scaleTargetRef": map[string]any{"name": "sample"}},
+	}}
+
+	t.Run("should suppress the replicas of the workloads scaled by hpa", func(t *testing.T) {
+		drift, _ := newMutatorsDrift(t, []string{MutatorHPA}, []runtime.Object{hpa})
+
+		dvn := newReplicasDeviation()
+		require.NoError(t, drift.suppressMutations(t.Context(), dvn, "sample"))
+
+		assert.False(t, dvn.HasDrift)
+		assert.Empty(t, dvn.Deviations)
+		assert.Empty(t, dvn.Changes)
+		require.Len(t, dvn.Suppressed, 1)
+		assert.Equal(t, MutatorHPA, dvn.Suppressed[0].SuppressedBy)
+	})
+
+	t.Run("should report the changes on the fields not owned by the mutators", func(t *testing.T) {
+		drift, _ := newMutatorsDrift(t, []string{MutatorHPA}, []runtime.Object{hpa})
+
+		dvn := newReplicasDeviation()
+		dvn.Changes = append(dvn.Changes, &deviation.Change{
+			Path: "spec.template.spec.containers[0].image", Type: deviation.ChangeModified, Desired: "sample:v2", Live: "sample:v1",
+		})
+		require.NoError(t, drift.suppressMutations(t.Context(), dvn, "sample"))
+
+		assert.True(t, dvn.HasDrift)
+		assert.Equal(t, []string{"spec.template.spec.containers[0].image"}, changePaths(dvn.Changes))
+		assert.Equal(t, []string{"spec.replicas"}, changePaths(dvn.Suppressed))
+	})
+
+	t.Run("should not suppress the replicas of the workloads that are not scaled", func(t *testing.T) {
+		drift, _ := newMutatorsDrift(t, []string{MutatorHPA, MutatorKEDA}, nil)
+
+		dvn := newReplicasDeviation()
+		require.NoError(t, drift.suppressMutations(t.Context(), dvn, "sample"))
+
+		assert.True(t, dvn.HasDrift)
+		assert.Empty(t, dvn.Suppressed)
+	})
+
+	t.Run("should suppress the replicas of the workloads scaled by keda", func(t *testing.T) {
+		drift, _ := newMutatorsDrift(t, []string{MutatorKEDA}, nil, scaledObject)
+
+		dvn := newReplicasDeviation()
+		require.NoError(t, drift.suppressMutations(t.Context(), dvn, "sample"))
+
+		assert.False(t, dvn.HasDrift)
+		require.Len(t, dvn.Suppressed, 1)
+		assert.Equal(t, MutatorKEDA, dvn.Suppressed[0].SuppressedBy)
+	})
+
+	t.Run("should target no resources when the custom resources are not served by the cluster", func(t *testing.T) {
+		drift, dynamicClient := newMutatorsDrift(t, []string{MutatorKEDA}, nil)
+		dynamicClient.PrependReactor("list", "scaledobjects", func(_ k8sTesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewNotFound(kedaScaledObjectResource.GroupResource(), "")
+		})
+
+		dvn := newReplicasDeviation()
+		require.NoError(t, drift.suppressMutations(t.Context(), dvn, "sample"))
+
+		assert.True(t, dvn.HasDrift)
+	})
+
+	t.Run("should suppress the selector of the services of argo rollouts", func(t *testing.T) {
+		rollout := &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "Rollout",
+			"metadata":   map[string]any{"name": "sample", "namespace": "sample"},
+			"spec": map[string]any{
+				"workloadRef": map[string]any{"kind": "Deployment", "name": "sample"},
+				"strategy":    map[string]any{"canary": map[string]any{"canaryService": "sample-canary", "stableService": "sample"}},
+			},
+		}}
+		drift, _ := newMutatorsDrift(t, []string{MutatorArgoRollouts}, nil, rollout)
+
+		dvn := &deviation.Deviation{
+			Kind: "Service", Resource: "sample-canary", HasDrift: true,
+			Changes: []*deviation.Change{
+				{Path: "spec.selector.rollouts-pod-template-hash", Type: deviation.ChangeRemoved, Live: "5d4f8c"},
+			},
+		}
+		require.NoError(t, drift.suppressMutations(t.Context(), dvn, "sample"))
+		assert.False(t, dvn.HasDrift)
+
+		dvn = newReplicasDeviation()
+		require.NoError(t, drift.suppressMutations(t.Context(), dvn, "sample"))
+		assert.False(t, dvn.HasDrift)
+	})
+
+	t.Run("should suppress the resources of the workloads updated by vpa", func(t *testing.T) {
+		newVPA := func(name, updateMode string) *unstructured.Unstructured {
+			return &unstructured.Unstructured{Object: map[string]any{
+				"apiVersion": "autoscaling.k8s.io/v1",
+				"kind":       "VerticalPodAutoscaler",
+				"metadata":   map[string]any{"name": name, "namespace": "sample"},
+				"spec": map[string]any{
+					"targetRef":    map[string]any{"apiVersion": "apps/v1", "kind": "Deployment", "name": name},
+					"updatePolicy": map[string]any{"updateMode": updateMode},
+				},
+			}}
+		}
+		drift, _ := newMutatorsDrift(t, nil, nil, newVPA("sample", "Auto"), newVPA("recommended", "Off"))
+		drift.IgnoreVPAChanges = true
+		require.NoError(t, drift.SetMutators())
+
+		newResourcesDeviation := func(name string) *deviation.Deviation {
+			return &deviation.Deviation{
+				Kind: "Deployment", Resource: name, HasDrift: true,
+				Changes: []*deviation.Change{
+					{Path: "spec.template.spec.containers[0].resources.requests.cpu", Type: deviation.ChangeModified, Desired: "100m", Live: "250m"},
+					{Path: "spec.template.spec.containers[0].resources.limits.memory", Type: deviation.ChangeModified, Desired: "128Mi", Live: "512Mi"},
+				},
+			}
+		}
+
+		dvn := newResourcesDeviation("sample")
+		require.NoError(t, drift.suppressMutations(t.Context(), dvn, "sample"))
+
+		assert.False(t, dvn.HasDrift)
+		require.Len(t, dvn.Suppressed, 2)
+		assert.Equal(t, MutatorVPA, dvn.Suppressed[0].SuppressedBy)
+
+		dvn = newResourcesDeviation("recommended")
+		require.NoError(t, drift.suppressMutations(t.Context(), dvn, "sample"))
+
+		assert.True(t, dvn.HasDrift)
+		assert.Empty(t, dvn.Suppressed)
+	})
+
+	t.Run("should suppress the ca bundles injected by cert-manager", func(t *testing.T) {
+		drift, _ := newMutatorsDrift(t, []string{MutatorCertManager}, nil)
+
+		webhook := map[string]any{
+			"apiVersion": "admissionregistration.k8s.io/v1",
+			"kind":       "ValidatingWebhookConfiguration",
+			"metadata": map[string]any{
+				"name":        "sample",
+				"annotations": map[string]any{"cert-manager.io/inject-ca-from": "sample/sample-tls"},
+			},
+		}
+
+		dvn := &deviation.Deviation{
+			Kind: "ValidatingWebhookConfiguration", Resource: "sample", HasDrift: true, ManifestPath: writeManifest(t, webhook),
+			Changes: []*deviation.Change{
+				{Path: "webhooks[0].clientConfig.caBundle", Type: deviation.ChangeModified, Desired: "", Live: "LS0tLS1CRUdJTi..."},
+			},
+		}
+		require.NoError(t, drift.suppressMutations(t.Context(), dvn, "sample"))
+		assert.False(t, dvn.HasDrift)
+
+		delete(webhook["metadata"].(map[string]any), "annotations")
+
+		dvn.HasDrift, dvn.ManifestPath = true, writeManifest(t, webhook)
+		dvn.Changes, dvn.Suppressed = dvn.Suppressed, nil
+		require.NoError(t, drift.suppressMutations(t.Context(), dvn, "sample"))
+		assert.True(t, dvn.HasDrift)
+	})
+
+	t.Run("should suppress the sidecars injected by istio", func(t *testing.T) {
+		drift, _ := newMutatorsDrift(t, []string{MutatorIstio}, nil)
+
+		dvn := &deviation.Deviation{
+			Kind: "Pod", Resource: "sample", HasDrift: true,
+			Changes: []*deviation.Change{
+				{Path: `metadata.annotations.sidecar\.istio\.io/status`, Type: deviation.ChangeRemoved, Live: "{}"},
+				{Path: "spec.containers[1]", Type: deviation.ChangeRemoved, Live: map[string]any{"name": "istio-proxy"}},
+				{Path: "spec.initContainers[0]", Type: deviation.ChangeRemoved, Live: map[string]any{"name": "istio-init"}},
+				{Path: "spec.containers[2]", Type: deviation.ChangeRemoved, Live: map[string]any{"name": "logger"}},
+			},
+		}
+		require.NoError(t, drift.suppressMutations(t.Context(), dvn, "sample"))
+
+		assert.True(t, dvn.HasDrift)
+		assert.Equal(t, []string{"spec.containers[2]"}, changePaths(dvn.Changes))
+		assert.Len(t, dvn.Suppressed, 3)
+	})
+
+	t.Run("should suppress the changes owned by the custom mutators", func(t *testing.T) {
+		drift := New(WithMutators(&Mutator{
+			Name:  "restarts",
+			Paths: []string{`spec.template.metadata.annotations.kubectl\.kubernetes\.io/restartedAt`},
+			Targets: func(_ context.Context, _ *Drift, dvn *deviation.Deviation, _ string) (bool, error) {
+				return dvn.Kind == "Deployment", nil
+			},
+		}))
+		drift.SetLogger("error")
+
+		dvn := &deviation.Deviation{
+			Kind: "Deployment", Resource: "sample", HasDrift: true,
+			Changes: []*deviation.Change{
+				{Path: `spec.template.metadata.annotations.kubectl\.kubernetes\.io/restartedAt`, Type: deviation.ChangeRemoved, Live: "now"},
+			},
+		}
+		require.NoError(t, drift.suppressMutations(t.Context(), dvn, "sample"))
+
+		assert.False(t, dvn.HasDrift)
+		assert.Equal(t, "restarts", dvn.Suppressed[0].SuppressedBy)
+	})
+}
+
+func TestKubectlDiffSuppressesMutations(t *testing.T) {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "sample"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "sample"},
+		},
+	}
+
+	// newResourcesDeployment returns the Deployment 'sample' whose container requests the cpu and memory passed.
+	newResourcesDeployment := func(replicas int64, cpu, memory string) *unstructured.Unstructured {
+		deployment := newDeployment(replicas)
+		_ = unstructured.SetNestedSlice(deployment.Object, []any{
+			map[string]any{"name": "app", "resources": map[string]any{"requests": map[string]any{"cpu": cpu, "memory": memory}}},
+		}, "spec", "template", "spec", "containers")
+
+		return deployment
+	}
+
+	newDeviation := func(t *testing.T) *deviation.Deviation {
+		t.Helper()
+
+		desired := newResourcesDeployment(1, "1000m", "1024Mi")
+		desired.SetManagedFields(nil)
+
+		return &deviation.Deviation{Kind: "Deployment", Resource: "sample", ManifestPath: writeManifest(t, desired.Object)}
+	}
+
+	t.Run("should suppress the replicas scaled by hpa when the rest of the manifest is only canonicalised", func(t *testing.T) {
+		drift, dynamicClient := newMutatorsDrift(t, nil, []runtime.Object{hpa}, newResourcesDeployment(3, "1", "1Gi"))
+		drift.IgnoreHPAChanges = true
+		require.NoError(t, drift.SetMutators())
+
+		// the API server canonicalises the quantities of the manifest applied, cpu '1000m' is '1' and memory '1024Mi' is '1Gi'.
+		dynamicClient.PrependReactor("patch", "*", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+			_, applied, err := testutil.DryRunApplyReactor(dynamicClient)(action)
+			if err != nil {
+				return true, nil, err
+			}
+
+			merged := applied.(*unstructured.Unstructured)
+			containers, _, _ := unstructured.NestedSlice(merged.Object, "spec", "template", "spec", "containers")
+			containers[0].(map[string]any)["resources"] = map[string]any{"requests": map[string]any{"cpu": "1", "memory": "1Gi"}}
+
+			return true, merged, unstructured.SetNestedSlice(merged.Object, containers, "spec", "template", "spec", "containers")
+		})
+
+		exec := &testutil.FakeExec{Diff: "-  replicas: 3\n+  replicas: 1\n"}
+		WithCommandExecutor(exec.Executor())(drift)
+
+		dvn, err := drift.diffResource(t.Context(), newDeviation(t), "sample")
+		require.NoError(t, err)
+
+		assert.False(t, dvn.HasDrift)
+		assert.Empty(t, dvn.Deviations)
+		assert.Empty(t, dvn.Changes)
+		require.Equal(t, []string{"spec.replicas"}, changePaths(dvn.Suppressed))
+		assert.Equal(t, MutatorHPA, dvn.Suppressed[0].SuppressedBy)
+	})
+
+	t.Run("should report the resource drifted when the changes could not be identified", func(t *testing.T) {
+		drift, dynamicClient := newMutatorsDrift(t, []string{MutatorHPA}, []runtime.Object{hpa}, newResourcesDeployment(3, "1", "1Gi"))
+		dynamicClient.PrependReactor("patch", "*", func(_ k8sTesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewForbidden(testutil.DeploymentResource.GroupResource(), "sample", nil)
+		})
+
+		exec := &testutil.FakeExec{Diff: "-  replicas: 3\n+  replicas: 1\n"}
+		WithCommandExecutor(exec.Executor())(drift)
+
+		dvn, err := drift.diffResource(t.Context(), newDeviation(t), "sample")
+		require.NoError(t, err)
+
+		assert.True(t, dvn.HasDrift)
+		assert.Equal(t, "-  replicas: 3\n+  replicas: 1\n", dvn.Deviations)
+		assert.Empty(t, dvn.Changes)
+		assert.Empty(t, dvn.Suppressed)
+	})
+}
+
+func TestDropMutatedFields(t *testing.T) {
+	drift, _ := newMutatorsDrift(t, nil, []runtime.Object{&autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "sample"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "sample"},
+		},
+	}})
+
+	desired := newDeployment(1)
+	require.NoError(t, drift.dropMutatedFields(t.Context(), desired, &deviation.Deviation{Kind: "Deployment", Resource: "sample"}, "sample"))
+
+	assert.NotContains(t, desired.Object["spec"], "replicas")
+}
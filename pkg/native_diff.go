@@ -0,0 +1,266 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/thoas/go-funk"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	nativeDiffFieldManager = "helm-drift"
+	nativeDiffContextLines = 3
+	secretMask             = "***"
+	secretMaskBefore       = "*** (before)"
+	secretMaskAfter        = "*** (after)"
+)
+
+// nativeDiff identifies drifts of the manifest without kubectl, by comparing the live object against
+// the object the API server would persist if the manifest was applied (server-side apply in dry-run mode).
+func (drift *Drift) nativeDiff(
+	ctx context.Context, dvn *deviation.Deviation, nameSpace string, objects *resourceObjects,
+) (*deviation.Deviation, error) {
+	desired, err := readManifest(dvn.ManifestPath)
+	if err != nil {
+		return dvn, err
+	}
+
+	if len(desired.Object) == 0 {
+		drift.log.Debugf("manifest '%s' is empty, skipping it", dvn.ManifestPath)
+
+		return dvn, nil
+	}
+
+	live, merged, err := drift.dryRunApply(ctx, desired, dvn, nameSpace)
+	if err != nil {
+		return dvn, err
+	}
+
+	objects.set(desired, live)
+
+	managedFields := managedFieldsOf(live)
+
+	for _, object := range []*unstructured.Unstructured{live, merged} {
+		if err = drift.normalize(object); err != nil {
+			return dvn, err
+		}
+	}
+
+	diff, err := diffObjects(live, merged)
+	if err != nil {
+		return dvn, err
+	}
+
+	if len(diff) == 0 {
+		drift.log.Debugf("no diffs found for '%s' with name '%s'", dvn.Kind, dvn.Resource)
+
+		return dvn, nil
+	}
+
+	drift.log.Debugf("found diffs for '%s' with name '%s'", dvn.Kind, dvn.Resource)
+
+	dvn.HasDrift = true
+	dvn.Deviations = diff
+	dvn.Changes = objectChanges(merged, live, false)
+	attributeChanges(managedFields, live, dvn.Changes)
+
+	if live == nil {
+		dvn.Status = deviation.StatusMissing
+	}
+
+	return dvn, nil
+}
+
+// dryRunApply returns the live object of the manifest from the cluster, along with the object the API server would persist
+// if the manifest was applied (server-side apply in dry-run mode). Both of them are defaulted and canonicalised by the API server
+// (ex: cpu '1000m' is '1'), hence they could be compared as is. The live object returned is nil, when it does not exist in the cluster.
+func (drift *Drift) dryRunApply(
+	ctx context.Context, desired *unstructured.Unstructured, dvn *deviation.Deviation, nameSpace string,
+) (*unstructured.Unstructured, *unstructured.Unstructured, error) {
+	resourceClient, err := drift.getResourceClient(desired, nameSpace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	live, err := resourceClient.Get(ctx, desired.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return nil, nil, &errors.DriftError{Message: fmt.Sprintf("fetching '%s' '%s' from cluster errored with '%v'", dvn.Kind, dvn.Resource, err)}
+		}
+
+		drift.log.Debugf("'%s' '%s' does not exist in the cluster", dvn.Kind, dvn.Resource)
+
+		live = nil
+	}
+
+	merged, err := resourceClient.Apply(ctx, desired.GetName(), desired, metav1.ApplyOptions{
+		FieldManager: nativeDiffFieldManager,
+		Force:        true,
+		DryRun:       []string{metav1.DryRunAll},
+	})
+	if err != nil {
+		return nil, nil, &errors.DriftError{Message: fmt.Sprintf("dry-run apply of '%s' '%s' errored with '%v'", dvn.Kind, dvn.Resource, err)}
+	}
+
+	return live, merged, nil
+}
+
+// getResourceClient returns the dynamic client scoped to the resource and namespace of the object passed.
+func (drift *Drift) getResourceClient(object *unstructured.Unstructured, nameSpace string) (dynamic.ResourceInterface, error) {
+	restMapper, err := drift.getRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+
+	dynamicClient, err := drift.getDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	gvk := object.GroupVersionKind()
+
+	mapping, err := restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, &errors.DriftError{Message: fmt.Sprintf("identifying resource for '%s' errored with '%v'", gvk.String(), err)}
+	}
+
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return dynamicClient.Resource(mapping.Resource), nil
+	}
+
+	object.SetNamespace(nameSpace)
+
+	return dynamicClient.Resource(mapping.Resource).Namespace(nameSpace), nil
+}
+
+// diffObjects renders a unified diff between the live and the merged object in the same shape 'kubectl diff' does.
+func diffObjects(live, merged *unstructured.Unstructured) (string, error) {
+	return unifiedDiff(live, merged, "LIVE", "MERGED")
+}
+
+// unifiedDiff renders a unified diff between the objects, the names of the objects in the diff are prefixed with the labels passed.
+func unifiedDiff(from, to *unstructured.Unstructured, fromLabel, toLabel string) (string, error) {
+	liveObject, mergedObject := prepareForDiff(from), prepareForDiff(to)
+
+	if liveObject != nil && mergedObject != nil && mergedObject.GetKind() == "Secret" {
+		maskSecretData(liveObject, mergedObject)
+	}
+
+	liveYAML, err := objectToYAML(liveObject)
+	if err != nil {
+		return "", err
+	}
+
+	mergedYAML, err := objectToYAML(mergedObject)
+	if err != nil {
+		return "", err
+	}
+
+	name := diffObjectName(to)
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(liveYAML),
+		B:        difflib.SplitLines(mergedYAML),
+		FromFile: fromLabel + "/" + name,
+		ToFile:   toLabel + "/" + name,
+		Context:  nativeDiffContextLines,
+	})
+}
+
+func prepareForDiff(object *unstructured.Unstructured) *unstructured.Unstructured {
+	if object == nil {
+		return nil
+	}
+
+	object = object.DeepCopy()
+	unstructured.RemoveNestedField(object.Object, "metadata", "managedFields")
+
+	return object
+}
+
+// maskSecretData hides the values of the secret the way 'kubectl diff' does, while still revealing which keys changed.
+func maskSecretData(live, merged *unstructured.Unstructured) {
+	for _, field := range []string{"data", "stringData"} {
+		liveData, _, _ := unstructured.NestedMap(live.Object, field)
+		mergedData, _, _ := unstructured.NestedMap(merged.Object, field)
+
+		for key, liveValue := range liveData {
+			mergedValue, found := mergedData[key]
+
+			switch {
+			case !found:
+				liveData[key] = secretMask
+			case fmt.Sprint(liveValue) == fmt.Sprint(mergedValue):
+				liveData[key], mergedData[key] = secretMask, secretMask
+			default:
+				liveData[key], mergedData[key] = secretMaskBefore, secretMaskAfter
+			}
+		}
+
+		for key := range mergedData {
+			if _, found := liveData[key]; !found {
+				mergedData[key] = secretMask
+			}
+		}
+
+		if liveData != nil {
+			_ = unstructured.SetNestedMap(live.Object, liveData, field)
+		}
+
+		if mergedData != nil {
+			_ = unstructured.SetNestedMap(merged.Object, mergedData, field)
+		}
+	}
+}
+
+func objectToYAML(object *unstructured.Unstructured) (string, error) {
+	if object == nil {
+		return "", nil
+	}
+
+	out, err := yaml.Marshal(object.Object)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+func diffObjectName(object *unstructured.Unstructured) string {
+	gvk := object.GroupVersionKind()
+
+	parts := []string{gvk.Version, gvk.Kind, object.GetNamespace(), object.GetName()}
+	if len(gvk.Group) != 0 {
+		parts = append([]string{gvk.Group}, parts...)
+	}
+
+	return strings.Join(funk.FilterString(parts, func(part string) bool {
+		return len(part) != 0
+	}), ".")
+}
+
+// readManifest reads the manifest rendered on to disk as an unstructured object.
+func readManifest(manifestPath string) (*unstructured.Unstructured, error) {
+	manifest, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	object := make(map[string]any)
+	if err = yaml.Unmarshal(manifest, &object); err != nil {
+		return nil, &errors.DriftError{Message: fmt.Sprintf("parsing manifest '%s' errored with '%v'", manifestPath, err)}
+	}
+
+	return &unstructured.Unstructured{Object: object}, nil
+}
@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newDeployment(replicas int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]any{
+			"name":          "sample",
+			"namespace":     "sample",
+			"managedFields": []any{map[string]any{"manager": "helm"}},
+		},
+		"spec": map[string]any{"replicas": replicas},
+	}}
+}
+
+func TestDiffObjects(t *testing.T) {
+	t.Run("no diff when live and merged are same", func(t *testing.T) {
+		diff, err := diffObjects(newDeployment(1), newDeployment(1))
+		require.NoError(t, err)
+		assert.Empty(t, diff)
+	})
+
+	t.Run("renders unified diff when objects differ", func(t *testing.T) {
+		diff, err := diffObjects(newDeployment(2), newDeployment(1))
+		require.NoError(t, err)
+
+		assert.Contains(t, diff, "--- LIVE/apps.v1.Deployment.sample.sample")
+		assert.Contains(t, diff, "+++ MERGED/apps.v1.Deployment.sample.sample")
+		assert.Contains(t, diff, "-  replicas: 2")
+		assert.Contains(t, diff, "+  replicas: 1")
+		assert.NotContains(t, diff, "managedFields")
+	})
+
+	t.Run("renders whole object as addition when live is missing", func(t *testing.T) {
+		diff, err := diffObjects(nil, newDeployment(1))
+		require.NoError(t, err)
+
+		assert.Contains(t, diff, "+kind: Deployment")
+	})
+
+	t.Run("masks secret data", func(t *testing.T) {
+		newSecret := func(data map[string]any) *unstructured.Unstructured {
+			return &unstructured.Unstructured{Object: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata":   map[string]any{"name": "sample"},
+				"data":       data,
+			}}
+		}
+
+		diff, err := diffObjects(
+			newSecret(map[string]any{"password": "bGl2ZQ==", "user": "YWRtaW4="}),
+			newSecret(map[string]any{"password": "ZGVzaXJlZA==", "user": "YWRtaW4="}),
+		)
+		require.NoError(t, err)
+
+		assert.Contains(t, diff, "-  password: '*** (before)'")
+		assert.Contains(t, diff, "+  password: '*** (after)'")
+		assert.NotContains(t, diff, "bGl2ZQ==")
+		assert.NotContains(t, diff, "ZGVzaXJlZA==")
+	})
+}
+
+func TestReadManifest(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(deploymentManifest), 0o600))
+
+	object, err := readManifest(manifestPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Deployment", object.GetKind())
+	assert.Equal(t, "sample", object.GetName())
+	assert.Equal(t, "workloads", object.GetNamespace())
+}
+
+func TestDiffManifestUnsupportedEngine(t *testing.T) {
+	drift := Drift{DiffEngine: "unknown"}
+	drift.SetLogger("error")
+
+	_, err := drift.diffManifest(t.Context(), &deviation.Deviation{}, "sample", new(resourceObjects))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "helm drift does not support diff engine 'unknown'")
+}
@@ -1,7 +1,7 @@
 package pkg
 
 import (
-	log "github.com/sirupsen/logrus"
+	"github.com/nikhilsbhat/helm-drift/pkg/fields"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
@@ -20,7 +20,7 @@ func cleanResource(resource *unstructured.Unstructured) *unstructured.Unstructur
 	return resource
 }
 
-func (drift *Drift) dropStandardHelmLabels(resource *unstructured.Unstructured) *unstructured.Unstructured {
+func (drift *Drift) dropStandardHelmLabels(resource *unstructured.Unstructured) (*unstructured.Unstructured, error) {
 	helmLabels := []string{
 		"app.kubernetes.io/name",
 		"helm.sh/chart",
@@ -35,14 +35,14 @@ func (drift *Drift) dropStandardHelmLabels(resource *unstructured.Unstructured)
 	for _, label := range helmLabels {
 		labels, found, err := unstructured.NestedStringMap(resource.Object, "metadata", "labels")
 		if err != nil {
-			log.Fatalf("error: %v", err)
+			return nil, err
 		}
 
 		if found {
 			delete(labels, label)
 
-			if err := unstructured.SetNestedStringMap(resource.Object, labels, "metadata", "labels"); err != nil {
-				log.Fatalf("error: %v", err)
+			if err = unstructured.SetNestedStringMap(resource.Object, labels, "metadata", "labels"); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -50,19 +50,66 @@ func (drift *Drift) dropStandardHelmLabels(resource *unstructured.Unstructured)
 	for _, annotation := range helmAnnotations {
 		annotations, found, err := unstructured.NestedStringMap(resource.Object, "metadata", "annotations")
 		if err != nil {
-			log.Fatalf("error: %v", err)
+			return nil, err
 		}
 
 		if found {
 			delete(annotations, annotation)
 
-			if err := unstructured.SetNestedStringMap(resource.Object, annotations, "metadata", "annotations"); err != nil {
-				log.Fatalf("error: %v", err)
+			if err = unstructured.SetNestedStringMap(resource.Object, annotations, "metadata", "annotations"); err != nil {
+				return nil, err
 			}
 		}
 
 		unstructured.RemoveNestedField(resource.Object, "metadata.annotations", annotation)
 	}
 
-	return resource
+	return resource, nil
+}
+
+// dropEmptyFields drops the fields that are null, empty maps or empty lists, fields left empty once their children are dropped are dropped too.
+// Items of the lists are retained, so that the indexes of the items do not change.
+func dropEmptyFields(resource *unstructured.Unstructured) error {
+	dropEmpty(resource.Object)
+
+	return nil
+}
+
+func dropEmpty(value any) {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, item := range typed {
+			dropEmpty(item)
+
+			if isEmpty(item) {
+				delete(typed, key)
+			}
+		}
+	case []any:
+		for _, item := range typed {
+			dropEmpty(item)
+		}
+	}
+}
+
+func isEmpty(value any) bool {
+	switch typed := value.(type) {
+	case nil:
+		return true
+	case map[string]any:
+		return len(typed) == 0
+	case []any:
+		return len(typed) == 0
+	default:
+		return false
+	}
+}
+
+// dropFields returns the normalizer that drops the fields matching the path, see fields.Remove for the paths supported.
+func dropFields(path string) Normalizer {
+	return func(resource *unstructured.Unstructured) error {
+		fields.Remove(resource.Object, path)
+
+		return nil
+	}
 }
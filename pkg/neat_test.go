@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
@@ -58,7 +59,9 @@ func TestDrift_DropStandardHelmLabels(t *testing.T) {
 			},
 		}
 
-		cleanedResource := drift.dropStandardHelmLabels(resource)
+		cleanedResource, err := drift.dropStandardHelmLabels(resource)
+		require.NoError(t, err)
+
 		labels, _, _ := unstructured.NestedStringMap(cleanedResource.Object, "metadata", "labels")
 		annotations, _, _ := unstructured.NestedStringMap(cleanedResource.Object, "metadata", "annotations")
 
@@ -67,4 +70,20 @@ func TestDrift_DropStandardHelmLabels(t *testing.T) {
 		assert.NotContains(t, annotations, "meta.helm.sh/release-name")
 		assert.Equal(t, "keep", annotations["custom"])
 	})
+
+	t.Run("errors when labels are not strings", func(t *testing.T) {
+		drift := Drift{}
+		drift.SetLogger("debug")
+
+		resource := &unstructured.Unstructured{
+			Object: map[string]any{
+				"metadata": map[string]any{
+					"labels": map[string]any{"replicas": int64(1)},
+				},
+			},
+		}
+
+		_, err := drift.dropStandardHelmLabels(resource)
+		require.Error(t, err)
+	})
 }
@@ -0,0 +1,127 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// NormalizeClean drops the status and the metadata managed by the API server (ex: uid, resourceVersion, managedFields).
+	NormalizeClean = "clean"
+	// NormalizeHelmLabels drops the standard labels and annotations set by helm and the charts (ex: helm.sh/chart).
+	NormalizeHelmLabels = "helm-labels"
+	// NormalizeEmpty drops the fields that are null, empty maps or empty lists.
+	NormalizeEmpty = "empty"
+	// NormalizeDrop drops the fields matching the path passed along with it, ex: 'drop=spec.template.spec.containers[*].imagePullPolicy'.
+	NormalizeDrop = "drop"
+)
+
+// Normalizer normalizes the object in place before its drifts are identified, so that cosmetic differences are not identified as drifts.
+type Normalizer func(object *unstructured.Unstructured) error
+
+// WithNormalizers appends normalizers to the pipeline, they run after the steps selected with Normalize.
+func WithNormalizers(normalizers ...Normalizer) Option {
+	return func(drift *Drift) {
+		drift.customNormalizers = append(drift.customNormalizers, normalizers...)
+	}
+}
+
+// SetNormalizers sets the pipeline of normalizers from the steps selected with Normalize, steps run in the order they are selected.
+// Manifests are normalized before they are diffed, and so are the live objects fetched by helm drift (by the native engine and for the changes).
+// 'kubectl diff' fetches the live objects itself, but the fields dropped from the manifests are left as they are by its dry-run apply,
+// so they do not show up as drifts either.
+func (drift *Drift) SetNormalizers() error {
+	normalizers := make([]Normalizer, 0, len(drift.Normalize))
+
+	for _, step := range drift.Normalize {
+		name, path, _ := strings.Cut(strings.TrimSpace(step), "=")
+
+		switch name {
+		case NormalizeClean:
+			normalizers = append(normalizers, func(object *unstructured.Unstructured) error {
+				cleanResource(object)
+
+				return nil
+			})
+		case NormalizeHelmLabels:
+			normalizers = append(normalizers, func(object *unstructured.Unstructured) error {
+				_, err := drift.dropStandardHelmLabels(object)
+
+				return err
+			})
+		case NormalizeEmpty:
+			normalizers = append(normalizers, dropEmptyFields)
+		case NormalizeDrop:
+			if len(path) == 0 {
+				return &errors.DriftError{
+					Message: fmt.Sprintf("normalization step '%s' needs the path of the fields to be dropped, ex: 'drop=spec.replicas'", step),
+				}
+			}
+
+			normalizers = append(normalizers, dropFields(path))
+		default:
+			return &errors.DriftError{
+				Message: fmt.Sprintf("helm drift does not support normalization step '%s', it should be one of clean|helm-labels|empty|drop=<path>", step),
+			}
+		}
+	}
+
+	drift.normalizers = normalizers
+
+	return nil
+}
+
+// normalizes returns true when there are normalizers to be run.
+func (drift *Drift) normalizes() bool {
+	return len(drift.normalizers) != 0 || len(drift.customNormalizers) != 0
+}
+
+// normalize runs the pipeline of normalizers on the object in place,
+// the steps selected with Normalize run first followed by the ones added with WithNormalizers.
+func (drift *Drift) normalize(object *unstructured.Unstructured) error {
+	if object == nil || len(object.Object) == 0 {
+		return nil
+	}
+
+	for _, normalizers := range [][]Normalizer{drift.normalizers, drift.customNormalizers} {
+		for _, normalizer := range normalizers {
+			if err := normalizer(object); err != nil {
+				return &errors.DriftError{Message: fmt.Sprintf("normalizing '%s' '%s' errored with '%v'", object.GetKind(), object.GetName(), err)}
+			}
+		}
+	}
+
+	return nil
+}
+
+// normalizeManifest runs the pipeline of normalizers on the manifest of the template, before it is rendered on to disk or compared.
+func (drift *Drift) normalizeManifest(manifest string, template *deviation.Deviation) (string, error) {
+	if !drift.normalizes() {
+		return manifest, nil
+	}
+
+	object := make(map[string]any)
+	if err := yaml.Unmarshal([]byte(manifest), &object); err != nil {
+		return "", &errors.DriftError{Message: fmt.Sprintf("parsing manifest of '%s' '%s' errored with '%v'", template.Kind, template.Resource, err)}
+	}
+
+	if len(object) == 0 {
+		return manifest, nil
+	}
+
+	if err := drift.normalize(&unstructured.Unstructured{Object: object}); err != nil {
+		return "", err
+	}
+
+	out, err := yaml.Marshal(object)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
@@ -0,0 +1,131 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func TestSetNormalizers(t *testing.T) {
+	tests := []struct {
+		name      string
+		normalize []string
+		expected  int
+		err       string
+	}{
+		{name: "should set no normalizers when no steps are selected", normalize: nil, expected: 0},
+		{name: "should set the steps selected", normalize: []string{"clean", " helm-labels", "empty", "drop=spec.replicas"}, expected: 4},
+		{name: "should error when the path to drop is not set", normalize: []string{"drop"}, err: "needs the path of the fields to be dropped"},
+		{name: "should error on unsupported steps", normalize: []string{"sort"}, err: "does not support normalization step 'sort'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			drift := Drift{Normalize: test.normalize}
+			drift.SetLogger("error")
+
+			err := drift.SetNormalizers()
+			if len(test.err) != 0 {
+				require.ErrorContains(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, drift.normalizers, test.expected)
+		})
+	}
+}
+
+func TestNormalizeManifest(t *testing.T) {
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: sample
+  creationTimestamp: null
+  labels:
+    helm.sh/chart: sample-0.1.0
+    team: drift
+  annotations: {}
+spec:
+  revisionHistoryLimit: 10
+  template:
+    spec:
+      containers:
+      - name: sample
+        image: sample:latest
+        imagePullPolicy: IfNotPresent
+        resources: {}
+status: {}
+`
+	template := &deviation.Deviation{Kind: "Deployment", Resource: "sample"}
+
+	t.Run("should leave the manifest as is when there are no normalizers", func(t *testing.T) {
+		drift := Drift{}
+		drift.SetLogger("error")
+
+		normalized, err := drift.normalizeManifest(manifest, template)
+		require.NoError(t, err)
+		assert.Equal(t, manifest, normalized)
+	})
+
+	t.Run("should run the steps selected followed by the ones added", func(t *testing.T) {
+		drift := New(WithNormalizers(func(object *unstructured.Unstructured) error {
+			object.SetLabels(map[string]string{"normalized": "true"})
+
+			return nil
+		}))
+		drift.SetLogger("error")
+		drift.Normalize = []string{
+			"clean", "helm-labels", "empty", "drop=spec.template.spec.containers[*].imagePullPolicy", "drop=spec.revisionHistoryLimit",
+		}
+		require.NoError(t, drift.SetNormalizers())
+
+		normalized, err := drift.normalizeManifest(manifest, template)
+		require.NoError(t, err)
+
+		object := make(map[string]any)
+		require.NoError(t, yaml.Unmarshal([]byte(normalized), &object))
+
+		assert.Equal(t, map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]any{"name": "sample", "labels": map[string]any{"normalized": "true"}},
+			"spec": map[string]any{
+				"template": map[string]any{
+					"spec": map[string]any{
+						"containers": []any{map[string]any{"name": "sample", "image": "sample:latest"}},
+					},
+				},
+			},
+		}, object)
+	})
+
+	t.Run("should error when a normalizer errors", func(t *testing.T) {
+		drift := New(WithNormalizers(func(_ *unstructured.Unstructured) error {
+			return errors.New("failed")
+		}))
+		drift.SetLogger("error")
+
+		_, err := drift.normalizeManifest(manifest, template)
+		require.ErrorContains(t, err, "normalizing 'Deployment' 'sample' errored with 'failed'")
+	})
+}
+
+func TestSetChangesNormalized(t *testing.T) {
+	drift := New(newFakeClusterOptions(newDeployment(2))...)
+	drift.SetLogger("error")
+	drift.Normalize = []string{"clean", "drop=spec.replicas"}
+	require.NoError(t, drift.SetNormalizers())
+
+	dvn := &deviation.Deviation{Kind: "Deployment", Resource: "sample", HasDrift: true, ManifestPath: writeManifest(t, newDeployment(1).Object)}
+
+	require.NoError(t, drift.setChanges(t.Context(), dvn, "sample", new(resourceObjects)))
+
+	assert.Empty(t, dvn.Changes, "the fields dropped by the normalizers from the live and merged object should not be changes")
+}
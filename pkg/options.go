@@ -0,0 +1,125 @@
+package pkg
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/command"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/util/homedir"
+)
+
+// Option configures the Drift returned by New.
+type Option func(drift *Drift)
+
+// New returns Drift with the same defaults the flags of helm drift have, and applies the options passed on top of them.
+// Exported fields of the Drift returned could still be set before identifying drifts with Detect.
+func New(opts ...Option) *Drift {
+	drift := &Drift{
+		Regex:           TemplateRegex,
+		TempPath:        filepath.Join(homedir.HomeDir(), ".helm-drift", "templates"),
+		DiffEngine:      DiffEngineKubectl,
+		Concurrency:     1,
+		IgnoreHookTypes: []string{"hook-succeeded", "hook-failed"},
+		LogLevel:        logrus.InfoLevel.String(),
+	}
+
+	drift.SetLogger(drift.LogLevel)
+	drift.SetWriter(os.Stdout)
+	drift.SetNamespace("")
+	drift.SetKubeConfig("")
+
+	for _, opt := range opts {
+		opt(drift)
+	}
+
+	return drift
+}
+
+// WithLogger sets the logger to be used by helm drift.
+func WithLogger(logger *logrus.Logger) Option {
+	return func(drift *Drift) {
+		drift.log = logger
+	}
+}
+
+// WithWriter sets the writer to which the drifts are rendered.
+func WithWriter(writer io.Writer) Option {
+	return func(drift *Drift) {
+		drift.SetWriter(writer)
+	}
+}
+
+// WithReader sets the reader from which the confirmations are read, when fixing drifts interactively.
+func WithReader(reader io.Reader) Option {
+	return func(drift *Drift) {
+		drift.SetReader(reader)
+	}
+}
+
+// WithKubeConfig sets the path to the kubeconfig and the context from it, used to connect to the cluster.
+func WithKubeConfig(kubeConfig, kubeContext string) Option {
+	return func(drift *Drift) {
+		drift.SetKubeConfig(kubeConfig)
+		drift.SetKubeContext(kubeContext)
+	}
+}
+
+// WithKubeClient sets the kubernetes client to be used, instead of the one created from the kubeconfig.
+func WithKubeClient(client kubernetes.Interface) Option {
+	return func(drift *Drift) {
+		drift.kubeClientOnce.Do(func() {
+			drift.kubeClient = client
+		})
+	}
+}
+
+// WithDynamicClient sets the dynamic client to be used by the native diff engine, instead of the one created from the kubeconfig.
+func WithDynamicClient(client dynamic.Interface) Option {
+	return func(drift *Drift) {
+		drift.dynamicClientOnce.Do(func() {
+			drift.dynamicClient = client
+		})
+	}
+}
+
+// WithMetadataClient sets the metadata client used to list the objects owned by the releases while identifying orphans,
+// instead of the one created from the kubeconfig.
+func WithMetadataClient(client metadata.Interface) Option {
+	return func(drift *Drift) {
+		drift.metadataClientOnce.Do(func() {
+			drift.metadataClient = client
+		})
+	}
+}
+
+// WithRESTMapper sets the RESTMapper used to map the kinds of the manifests to the resources of the cluster,
+// instead of the one discovered with the kubernetes client.
+func WithRESTMapper(restMapper meta.RESTMapper) Option {
+	return func(drift *Drift) {
+		drift.restMapperOnce.Do(func() {
+			drift.restMapper = restMapper
+		})
+	}
+}
+
+// WithCommandExecutor sets the Executor used to run the kubectl and helm commands, instead of command.NewCommand.
+func WithCommandExecutor(executor command.Executor) Option {
+	return func(drift *Drift) {
+		drift.commandExecutor = executor
+	}
+}
+
+func (drift *Drift) newCommand(ctx context.Context, cmd string) command.Exec {
+	if drift.commandExecutor != nil {
+		return drift.commandExecutor(ctx, cmd, drift.log)
+	}
+
+	return command.NewCommand(ctx, cmd, drift.log)
+}
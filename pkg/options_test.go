@@ -0,0 +1,235 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nikhilsbhat/helm-drift/internal/testutil"
+	"github.com/nikhilsbhat/helm-drift/pkg/command"
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+)
+
+// newFakeClusterOptions returns the options to have helm drift talk to a fake cluster with the objects passed.
+func newFakeClusterOptions(objects ...runtime.Object) []Option {
+	return []Option{
+		WithKubeClient(kubeFake.NewClientset()),
+		WithDynamicClient(testutil.NewFakeDynamicClient(objects...)),
+		WithRESTMapper(testutil.NewFakeRESTMapper()),
+	}
+}
+
+// writeManifest writes the object as a manifest on to the temp directory of the test, and returns its path.
+func writeManifest(t *testing.T, object map[string]any) string {
+	t.Helper()
+
+	manifest, err := yaml.Marshal(object)
+	require.NoError(t, err)
+
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	require.NoError(t, os.WriteFile(manifestPath, manifest, manifestFilePermission))
+
+	return manifestPath
+}
+
+func TestNew(t *testing.T) {
+	t.Run("should set the defaults the flags have", func(t *testing.T) {
+		drift := New()
+
+		assert.Equal(t, TemplateRegex, drift.Regex)
+		assert.Equal(t, DiffEngineKubectl, drift.DiffEngine)
+		assert.Equal(t, 1, drift.Concurrency)
+		assert.Equal(t, []string{"hook-succeeded", "hook-failed"}, drift.IgnoreHookTypes)
+		assert.Equal(t, "default", drift.namespace)
+		assert.NotNil(t, drift.log)
+		assert.NotNil(t, drift.writer)
+	})
+
+	t.Run("should apply the options passed", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+		logger := logrus.New()
+		exec := new(testutil.FakeExec)
+
+		drift := New(
+			WithLogger(logger),
+			WithWriter(buffer),
+			WithKubeConfig("/tmp/kubeconfig", "kind-kind"),
+			WithCommandExecutor(func(context.Context, string, *logrus.Logger) command.Exec { return exec }),
+		)
+
+		assert.Same(t, logger, drift.log)
+		assert.Equal(t, "/tmp/kubeconfig", drift.kubeConfig)
+		assert.Equal(t, "kind-kind", drift.kubeContext)
+		assert.Same(t, exec, drift.newCommand(t.Context(), "kubectl"))
+
+		drift.write("hello")
+		require.NoError(t, drift.flush())
+		assert.Equal(t, "hello", buffer.String())
+	})
+}
+
+func TestDetect(t *testing.T) {
+	t.Run("should error when release is not set", func(t *testing.T) {
+		_, err := New().Detect(t.Context(), Target{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "release to identify drifts from cannot be empty")
+	})
+}
+
+func TestDiffWithInjectedClients(t *testing.T) {
+	live := newDeployment(2)
+
+	desired := newDeployment(1)
+	desired.SetManagedFields(nil)
+
+	exec := &testutil.FakeExec{Diff: "-  replicas: 2\n+  replicas: 1\n"}
+
+	drift := New(append(newFakeClusterOptions(live),
+		WithCommandExecutor(exec.Executor()),
+	)...)
+	drift.SetLogger("error")
+
+	out, err := drift.Diff(t.Context(), &deviation.DriftedRelease{
+		Release:   "sample",
+		Namespace: "sample",
+		Deviations: []*deviation.Deviation{
+			{Kind: "Deployment", Resource: "sample", ManifestPath: writeManifest(t, desired.Object)},
+		},
+	})
+	require.NoError(t, err)
+
+	require.True(t, out.HasDrift)
+	assert.Contains(t, exec.Args, "--concurrency=1")
+	assert.Equal(t, []*deviation.Change{
+		{Path: "spec.replicas", Type: deviation.ChangeModified, Desired: float64(1), Live: float64(2)},
+	}, out.Deviations[0].Changes)
+}
+
+func TestDiffMissing(t *testing.T) {
+	newRelease := func(t *testing.T) *deviation.DriftedRelease {
+		t.Helper()
+
+		return &deviation.DriftedRelease{
+			Release:   "sample",
+			Namespace: "sample",
+			Deviations: []*deviation.Deviation{
+				{Kind: "Deployment", Resource: "sample", ManifestPath: writeManifest(t, newDeployment(1).Object)},
+				{Kind: "Deployment", Resource: "deleted", ManifestPath: writeManifest(t, newOwnedDeployment("deleted", "", map[string]any{}).Object)},
+			},
+		}
+	}
+
+	newDrift := func() *Drift {
+		exec := &testutil.FakeExec{Diff: "+  replicas: 1\n"}
+
+		drift := New(append(newFakeClusterOptions(newDeployment(2)), WithCommandExecutor(exec.Executor()))...)
+		drift.SetLogger("error")
+
+		return drift
+	}
+
+	t.Run("should mark the resources with no live object as missing", func(t *testing.T) {
+		out, err := newDrift().Diff(t.Context(), newRelease(t))
+		require.NoError(t, err)
+
+		require.Len(t, out.Deviations, 2)
+		assert.Empty(t, out.Deviations[0].Status)
+		assert.Equal(t, deviation.StatusMissing, out.Deviations[1].Status)
+		assert.Equal(t, "MISSING", out.Deviations[1].Drifted())
+	})
+
+	t.Run("should report the drifts without the changes when the live object of the resource could not be fetched", func(t *testing.T) {
+		configMap := map[string]any{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]any{"name": "sample"}}
+
+		release := newRelease(t)
+		release.Deviations = append(release.Deviations, &deviation.Deviation{
+			Kind: "ConfigMap", Resource: "sample", ManifestPath: writeManifest(t, configMap),
+		})
+
+		out, err := newDrift().Diff(t.Context(), release)
+		require.NoError(t, err)
+
+		require.Len(t, out.Deviations, 3)
+		assert.True(t, out.Deviations[2].HasDrift)
+		assert.Empty(t, out.Deviations[2].Changes)
+	})
+
+	t.Run("should mark the resources as missing when the live side of the kubectl diff is empty", func(t *testing.T) {
+		exec := &testutil.FakeExec{Diff: "--- /tmp/LIVE-1/apps.v1.Deployment.sample.deleted\n+++ /tmp/MERGED-1/apps.v1.Deployment.sample.deleted\n" +
+			"@@ -0,0 +1,2 @@\n+kind: Deployment\n+  replicas: 1\n"}
+
+		drift := New(append(newFakeClusterOptions(), WithCommandExecutor(exec.Executor()))...)
+		drift.SetLogger("error")
+
+		dvn := &deviation.Deviation{Kind: "Deployment", Resource: "deleted", ManifestPath: writeManifest(t, newDeployment(1).Object)}
+
+		out, err := drift.kubectlDiff(t.Context(), dvn, "sample", new(resourceObjects))
+		require.NoError(t, err)
+
+		assert.Equal(t, deviation.StatusMissing, out.Status)
+	})
+
+	t.Run("should report only the missing resources when selected", func(t *testing.T) {
+		drift := newDrift()
+		drift.OnlyMissing = true
+
+		out, err := drift.Diff(t.Context(), newRelease(t))
+		require.NoError(t, err)
+
+		require.Len(t, out.Deviations, 1)
+		assert.Equal(t, "deleted", out.Deviations[0].Resource)
+		assert.True(t, out.HasDrift)
+	})
+}
+
+func TestDiffTimeouts(t *testing.T) {
+	newRelease := func(t *testing.T) *deviation.DriftedRelease {
+		t.Helper()
+
+		return &deviation.DriftedRelease{
+			Release:   "sample",
+			Namespace: "sample",
+			Deviations: []*deviation.Deviation{
+				{Kind: "Deployment", Resource: "sample", ManifestPath: writeManifest(t, newDeployment(1).Object)},
+			},
+		}
+	}
+
+	t.Run("should report resources exceeding the resource timeout as timed-out", func(t *testing.T) {
+		exec := &testutil.FakeExec{Hang: true}
+
+		drift := New(append(newFakeClusterOptions(), WithCommandExecutor(exec.Executor()))...)
+		drift.SetLogger("error")
+		drift.ResourceTimeout = Duration(10 * time.Millisecond)
+
+		out, err := drift.Diff(t.Context(), newRelease(t))
+		require.NoError(t, err)
+
+		assert.False(t, out.HasDrift)
+		assert.Equal(t, deviation.StatusTimedOut, out.Deviations[0].Status)
+		assert.Equal(t, "TIMED-OUT", out.Deviations[0].Drifted())
+	})
+
+	t.Run("should error when identifying drifts is cancelled", func(t *testing.T) {
+		exec := &testutil.FakeExec{Hang: true}
+
+		drift := New(append(newFakeClusterOptions(), WithCommandExecutor(exec.Executor()))...)
+		drift.SetLogger("error")
+
+		ctx, cancel := context.WithCancel(t.Context())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		_, err := drift.Diff(ctx, newRelease(t))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), context.Canceled.Error())
+	})
+}
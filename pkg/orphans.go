@@ -0,0 +1,289 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"github.com/thoas/go-funk"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+const (
+	releaseNameAnnotation      = "meta.helm.sh/release-name"
+	releaseNamespaceAnnotation = "meta.helm.sh/release-namespace"
+	helmHookAnnotation         = "helm.sh/hook"
+	orphanListPageSize         = 500
+)
+
+// ownedObject is the object from the cluster annotated as owned by a helm release.
+type ownedObject struct {
+	apiVersion string
+	kind       string
+	name       string
+	namespace  string
+}
+
+// ownedObjectsEntry caches the objects of a namespace owned by helm releases, grouped by the release.
+// Objects are listed once for the namespace, without holding the lock of the cache while they are listed.
+type ownedObjectsEntry struct {
+	once    sync.Once
+	objects map[string][]ownedObject
+	err     error
+}
+
+// listableResourcesEntry caches the resources whose objects could be listed, they are discovered once.
+type listableResourcesEntry struct {
+	once      sync.Once
+	resources []listableResource
+	err       error
+}
+
+// listableResource is the resource from the cluster whose objects could be listed, to look for the ones owned by helm releases.
+type listableResource struct {
+	resource   schema.GroupVersionResource
+	apiVersion string
+	kind       string
+	namespaced bool
+}
+
+// getOrphans returns the objects from the cluster that are annotated as owned by the release,
+// but are no longer part of the manifests rendered for it.
+// Only the namespaces the release and its manifests are in, along with the cluster-scoped resources, are looked up for them.
+func (drift *Drift) getOrphans(ctx context.Context, release *deviation.DriftedRelease) ([]*deviation.Deviation, error) {
+	rendered := make(map[string]struct{}, len(release.Deviations))
+	nameSpaces := []string{release.Namespace}
+
+	for _, dvn := range release.Deviations {
+		nameSpace := drift.setNameSpace(release, dvn)
+
+		rendered[orphanKey(dvn.Kind, dvn.Resource, "")] = struct{}{}
+		rendered[orphanKey(dvn.Kind, dvn.Resource, nameSpace)] = struct{}{}
+		nameSpaces = append(nameSpaces, nameSpace)
+	}
+
+	ownedObjects, err := drift.helmOwnedObjects(ctx, release, funk.UniqString(nameSpaces))
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := make([]*deviation.Deviation, 0)
+
+	for _, object := range ownedObjects {
+		if !drift.selectsOrphan(object) {
+			continue
+		}
+
+		if _, ok := rendered[orphanKey(object.kind, object.name, object.namespace)]; ok {
+			continue
+		}
+
+		drift.log.Debugf("'%s' '%s' is owned by release '%s' but is not part of its manifests anymore", object.kind, object.name, release.Release)
+
+		orphans = append(orphans, &deviation.Deviation{
+			Kind:       object.kind,
+			Resource:   object.name,
+			NameSpace:  object.namespace,
+			APIVersion: object.apiVersion,
+			HasDrift:   true,
+			Status:     deviation.StatusOrphaned,
+		})
+	}
+
+	return orphans, nil
+}
+
+// selectsOrphan reports whether the object is selected by the flags that limit the drift identification (--kind, --skip and --name),
+// so that the objects left out of the rendered manifests by them are not reported as orphans.
+func (drift *Drift) selectsOrphan(object ownedObject) bool {
+	if len(drift.Kind) != 0 && !funk.ContainsString(drift.Kind, object.kind) {
+		return false
+	}
+
+	if funk.ContainsString(drift.SkipKinds, object.kind) {
+		return false
+	}
+
+	return len(drift.Name) == 0 || object.name == drift.Name
+}
+
+// helmOwnedObjects returns the objects of the cluster-scoped resources, and of the namespaced ones from the namespaces passed,
+// that are annotated as owned by the release. Only the metadata of the objects is listed, with the metadata client.
+// Objects are listed once per namespace and cached, grouped by the release, since they are looked up for every release.
+// Objects owned by controllers (ex: ReplicaSets inheriting the annotations of the Deployment) and hooks are left out.
+func (drift *Drift) helmOwnedObjects(ctx context.Context, release *deviation.DriftedRelease, nameSpaces []string) ([]ownedObject, error) {
+	ownedObjects := make([]ownedObject, 0)
+
+	// objects of the cluster-scoped resources are cached against the empty namespace.
+	for _, nameSpace := range append([]string{""}, nameSpaces...) {
+		entry := drift.ownedObjectsEntry(nameSpace)
+		entry.once.Do(func() {
+			entry.objects, entry.err = drift.listOwnedObjects(ctx, nameSpace)
+		})
+
+		if entry.err != nil {
+			return nil, entry.err
+		}
+
+		ownedObjects = append(ownedObjects, entry.objects[releaseKey(release.Release, release.Namespace)]...)
+	}
+
+	sort.Slice(ownedObjects, func(i, j int) bool {
+		return orphanKey(ownedObjects[i].kind, ownedObjects[i].name, ownedObjects[i].namespace) <
+			orphanKey(ownedObjects[j].kind, ownedObjects[j].name, ownedObjects[j].namespace)
+	})
+
+	return ownedObjects, nil
+}
+
+// ownedObjectsEntry returns the entry caching the objects owned by helm releases from the namespace, the lock is held only to look it up.
+func (drift *Drift) ownedObjectsEntry(nameSpace string) *ownedObjectsEntry {
+	drift.ownedObjectsMu.Lock()
+	defer drift.ownedObjectsMu.Unlock()
+
+	if drift.ownedObjects == nil {
+		drift.ownedObjects = make(map[string]*ownedObjectsEntry)
+	}
+
+	entry, ok := drift.ownedObjects[nameSpace]
+	if !ok {
+		entry = &ownedObjectsEntry{}
+		drift.ownedObjects[nameSpace] = entry
+	}
+
+	return entry
+}
+
+// listOwnedObjects lists the metadata of the objects from the namespace, or of the cluster-scoped ones when the namespace is empty,
+// that are annotated as owned by a helm release, grouped by the release.
+func (drift *Drift) listOwnedObjects(ctx context.Context, nameSpace string) (map[string][]ownedObject, error) {
+	metadataClient, err := drift.getMetadataClient()
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := drift.getListableResources()
+	if err != nil {
+		return nil, err
+	}
+
+	ownedObjects := make(map[string][]ownedObject)
+
+	for _, resource := range resources {
+		if resource.namespaced != (len(nameSpace) != 0) {
+			continue
+		}
+
+		listOptions := metav1.ListOptions{Limit: orphanListPageSize}
+
+		for {
+			objects, err := metadataClient.Resource(resource.resource).Namespace(nameSpace).List(ctx, listOptions)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, &errors.DriftError{Message: fmt.Sprintf("listing '%s' to identify orphans errored with '%v'", resource.resource.Resource, err)}
+				}
+
+				drift.log.Warnf("listing '%s' to identify orphans errored with '%v', hence skipping it", resource.resource.Resource, err)
+
+				break
+			}
+
+			for _, object := range objects.Items {
+				annotations := object.GetAnnotations()
+
+				release := annotations[releaseNameAnnotation]
+				if len(release) == 0 || len(annotations[helmHookAnnotation]) != 0 || metav1.GetControllerOf(&object) != nil {
+					continue
+				}
+
+				// the objects listed are PartialObjectMetadata, hence their api version and kind are the ones of the resource.
+				key := releaseKey(release, annotations[releaseNamespaceAnnotation])
+				ownedObjects[key] = append(ownedObjects[key], ownedObject{
+					apiVersion: resource.apiVersion,
+					kind:       resource.kind,
+					name:       object.GetName(),
+					namespace:  object.GetNamespace(),
+				})
+			}
+
+			if len(objects.GetContinue()) == 0 {
+				break
+			}
+
+			listOptions.Continue = objects.GetContinue()
+		}
+	}
+
+	return ownedObjects, nil
+}
+
+// getListableResources discovers the resources from the cluster whose objects could be listed, they are discovered once and cached.
+func (drift *Drift) getListableResources() ([]listableResource, error) {
+	drift.ownedObjectsMu.Lock()
+	if drift.listableResources == nil {
+		drift.listableResources = &listableResourcesEntry{}
+	}
+
+	entry := drift.listableResources
+	drift.ownedObjectsMu.Unlock()
+
+	entry.once.Do(func() {
+		entry.resources, entry.err = drift.discoverListableResources()
+	})
+
+	return entry.resources, entry.err
+}
+
+func (drift *Drift) discoverListableResources() ([]listableResource, error) {
+	clientSet, err := drift.getKubeClient()
+	if err != nil {
+		return nil, err
+	}
+
+	resourceLists, err := discovery.ServerPreferredResources(clientSet.Discovery())
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return nil, &errors.DriftError{Message: fmt.Sprintf("discovering resources to identify orphans errored with '%v'", err)}
+		}
+
+		drift.log.Warnf("resources of few groups could not be discovered, orphans from them would not be identified: %v", err)
+	}
+
+	resources := make([]listableResource, 0)
+
+	for _, resourceList := range resourceLists {
+		groupVersion, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			return nil, &errors.DriftError{Message: fmt.Sprintf("parsing group version '%s' errored with '%v'", resourceList.GroupVersion, err)}
+		}
+
+		for _, resource := range resourceList.APIResources {
+			if strings.Contains(resource.Name, "/") || !funk.ContainsString(resource.Verbs, "list") {
+				continue
+			}
+
+			resources = append(resources, listableResource{
+				resource:   groupVersion.WithResource(resource.Name),
+				apiVersion: resourceList.GroupVersion,
+				kind:       resource.Kind,
+				namespaced: resource.Namespaced,
+			})
+		}
+	}
+
+	return resources, nil
+}
+
+func releaseKey(release, nameSpace string) string {
+	return nameSpace + "/" + release
+}
+
+func orphanKey(kind, name, nameSpace string) string {
+	return kind + "/" + nameSpace + "/" + name
+}
@@ -0,0 +1,197 @@
+package pkg
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/internal/testutil"
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thoas/go-funk"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	discoveryFake "k8s.io/client-go/discovery/fake"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	metadataFake "k8s.io/client-go/metadata/fake"
+)
+
+func newOwnedDeployment(name, release string, annotations map[string]any) *unstructured.Unstructured {
+	object := newDeployment(1)
+	object.SetName(name)
+
+	if len(release) != 0 {
+		annotations[releaseNameAnnotation] = release
+		annotations[releaseNamespaceAnnotation] = "sample"
+	}
+
+	_ = unstructured.SetNestedMap(object.Object, annotations, "metadata", "annotations")
+
+	return object
+}
+
+// newPartialObjectMetadata returns the metadata of the object, the way the metadata client lists it.
+func newPartialObjectMetadata(object *unstructured.Unstructured) *metav1.PartialObjectMetadata {
+	return &metav1.PartialObjectMetadata{
+		TypeMeta: metav1.TypeMeta{APIVersion: object.GetAPIVersion(), Kind: object.GetKind()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            object.GetName(),
+			Namespace:       object.GetNamespace(),
+			Annotations:     object.GetAnnotations(),
+			OwnerReferences: object.GetOwnerReferences(),
+		},
+	}
+}
+
+func TestGetOrphans(t *testing.T) {
+	controller := true
+
+	controlled := newOwnedDeployment("controlled", "sample", map[string]any{})
+	controlled.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "v1", Kind: "Application", Name: "sample", Controller: &controller}})
+
+	elsewhere := newOwnedDeployment("elsewhere", "sample", map[string]any{})
+	elsewhere.SetNamespace("unrelated")
+
+	clusterRole := newOwnedDeployment("old-role", "sample", map[string]any{})
+	clusterRole.SetAPIVersion("rbac.authorization.k8s.io/v1")
+	clusterRole.SetKind("ClusterRole")
+	clusterRole.SetNamespace("")
+
+	objects := []*unstructured.Unstructured{
+		newOwnedDeployment("sample", "sample", map[string]any{}),
+		newOwnedDeployment("old", "sample", map[string]any{}),
+		newOwnedDeployment("hook", "sample", map[string]any{helmHookAnnotation: "pre-install"}),
+		newOwnedDeployment("other", "other", map[string]any{}),
+		newOwnedDeployment("unmanaged", "", map[string]any{}),
+		controlled,
+		elsewhere,
+		clusterRole,
+	}
+
+	metadataObjects := make([]runtime.Object, 0, len(objects))
+	for _, object := range objects {
+		metadataObjects = append(metadataObjects, newPartialObjectMetadata(object))
+	}
+
+	newDrift := func() *Drift {
+		kubeClient := kubeFake.NewClientset()
+		kubeClient.Discovery().(*discoveryFake.FakeDiscovery).Resources = []*metav1.APIResourceList{
+			{
+				GroupVersion: "apps/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "deployments", Kind: "Deployment", Namespaced: true, Verbs: []string{"get", "list"}},
+					{Name: "deployments/scale", Kind: "Scale", Namespaced: true, Verbs: []string{"get"}},
+				},
+			},
+			{
+				GroupVersion: "rbac.authorization.k8s.io/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "clusterroles", Kind: "ClusterRole", Verbs: []string{"get", "list"}},
+				},
+			},
+		}
+
+		scheme := runtime.NewScheme()
+		metav1.AddMetaToScheme(scheme)
+
+		drift := New(append([]Option{
+			WithKubeClient(kubeClient),
+			WithMetadataClient(metadataFake.NewSimpleMetadataClient(scheme, metadataObjects...)),
+		}, newFakeClusterOptions(newOwnedDeployment("sample", "sample", map[string]any{}))...)...)
+		drift.SetLogger("error")
+
+		return drift
+	}
+
+	newRelease := func() *deviation.DriftedRelease {
+		return &deviation.DriftedRelease{
+			Release:    "sample",
+			Namespace:  "sample",
+			Deviations: []*deviation.Deviation{{Kind: "Deployment", Resource: "sample"}},
+		}
+	}
+
+	t.Run("should report objects owned by the release that are not part of its manifests", func(t *testing.T) {
+		orphans, err := newDrift().getOrphans(t.Context(), newRelease())
+		require.NoError(t, err)
+
+		assert.Equal(t, []*deviation.Deviation{
+			{
+				Kind:       "ClusterRole",
+				Resource:   "old-role",
+				APIVersion: "rbac.authorization.k8s.io/v1",
+				HasDrift:   true,
+				Status:     deviation.StatusOrphaned,
+			},
+			{
+				Kind:       "Deployment",
+				Resource:   "old",
+				NameSpace:  "sample",
+				APIVersion: "apps/v1",
+				HasDrift:   true,
+				Status:     deviation.StatusOrphaned,
+			},
+		}, orphans, "objects from the namespaces the release has no manifests in should not be looked up")
+	})
+
+	t.Run("should look up the namespaces the manifests of the release are in", func(t *testing.T) {
+		release := newRelease()
+		release.Deviations = append(release.Deviations, &deviation.Deviation{Kind: "Service", Resource: "sample", NameSpace: "unrelated"})
+
+		orphans, err := newDrift().getOrphans(t.Context(), release)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"old-role", "old", "elsewhere"}, funk.Map(orphans, func(dvn *deviation.Deviation) string {
+			return dvn.Resource
+		}))
+	})
+
+	t.Run("should list the objects of every namespace once when releases are looked up concurrently", func(t *testing.T) {
+		drift := newDrift()
+
+		var waitGroup sync.WaitGroup
+
+		for range 5 {
+			waitGroup.Go(func() {
+				orphans, err := drift.getOrphans(t.Context(), newRelease())
+				assert.NoError(t, err)
+				assert.Len(t, orphans, 2)
+			})
+		}
+
+		waitGroup.Wait()
+
+		// deployments from the namespace 'sample' and the cluster roles.
+		assert.Len(t, drift.metadataClient.(*metadataFake.FakeMetadataClient).Actions(), 2)
+	})
+
+	t.Run("should not report objects left out by the flags limiting the drift identification", func(t *testing.T) {
+		drift := newDrift()
+		drift.SkipKinds = []string{"Deployment", "ClusterRole"}
+
+		orphans, err := drift.getOrphans(t.Context(), newRelease())
+		require.NoError(t, err)
+		assert.Empty(t, orphans)
+	})
+
+	t.Run("should report orphans along with the drifts when enabled", func(t *testing.T) {
+		exec := new(testutil.FakeExec)
+
+		drift := newDrift()
+		WithCommandExecutor(exec.Executor())(drift)
+		drift.DetectOrphans = true
+
+		release := newRelease()
+		release.Deviations[0].ManifestPath = writeManifest(t, newDeployment(1).Object)
+
+		out, err := drift.Diff(t.Context(), release)
+		require.NoError(t, err)
+
+		require.Len(t, out.Deviations, 3)
+		assert.True(t, out.HasDrift)
+		assert.Equal(t, "old-role", out.Deviations[1].Resource)
+		assert.Equal(t, "old", out.Deviations[2].Resource)
+		assert.Equal(t, "ORPHANED", out.Deviations[2].Drifted())
+	})
+}
@@ -2,57 +2,72 @@ package pkg
 
 import (
 	"fmt"
-	"os"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
 	"github.com/olekukonko/tablewriter"
 )
 
 func (drift *Drift) render(drifts []*deviation.DriftedRelease) error {
-	drift.write(addNewLine(""))
+	// reports consumed by other tools are written as is, the XML declaration has to be the first line of the JUnit report
+	// and the header the first row of the CSV report.
+	if drift.csv {
+		return drift.toCSV(drifts)
+	}
 
-	release := deviation.DriftedReleases(drifts)
+	if drift.junit {
+		return drift.toJUnit(drifts)
+	}
+
+	if drift.sarif {
+		return drift.toSARIF(drifts)
+	}
+
+	if drift.html {
+		return drift.toHTML(drifts)
+	}
+
+	if drift.markdown {
+		return drift.toMarkdown(drifts)
+	}
+
+	drift.write(addNewLine(""))
 
 	if drift.json || drift.yaml {
-		drift.flush()
+		if err := drift.flush(); err != nil {
+			return err
+		}
 
 		return drift.renderer.Render(drifts)
 	}
 
 	if drift.table {
 		drift.toTABLE(drifts)
-		drift.flush()
 
-		return nil
+		return drift.flush()
 	}
 
 	drift.print(drifts)
 
-	if release.Drifted() && !drift.DisableExitWithError {
-		drift.flush()
-
-		os.Exit(1)
-	}
-
-	drift.flush()
-
-	return nil
+	return drift.flush()
 }
 
 func (drift *Drift) toTABLE(drifts []*deviation.DriftedRelease) {
 	drift.log.Debug("rendering the drifts in table format since --summary is enabled")
 	table := drift.tableSchema()
 
-	switch drift.All {
-	case true:
+	switch {
+	case drift.All && drift.TableDetail == TableDetailResource:
+		drift.allResourceTable(table, drifts)
+	case drift.All:
 		drift.allTable(table, drifts)
 	default:
 		drift.runTable(table, drifts)
 	}
 
-	table.SetHeaderColor(tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold})
-
 	table.Render()
 	drift.write(addNewLine(fmt.Sprintf("Time spent in identifying drift: '%v'\n", drift.timeSpent)))
 }
@@ -60,19 +75,40 @@ func (drift *Drift) toTABLE(drifts []*deviation.DriftedRelease) {
 func (drift *Drift) runTable(table *tablewriter.Table, deviations []*deviation.DriftedRelease) bool {
 	drifts := deviations[0]
 
-	table.SetHeader([]string{"kind", "name", "drift"})
+	header := []string{"kind", "name", "drift"}
+	if drift.History {
+		header = append(header, "matched revision")
+	}
+
+	header = append(header, "changed by")
+
+	table.SetHeader(header)
+	table.SetHeaderColor(boldColors(len(header))...)
 
 	for _, dft := range drifts.Deviations {
 		tableRow := []string{dft.Kind, dft.Resource, dft.Drifted()}
+		if drift.History {
+			tableRow = append(tableRow, matchedRevision(dft))
+		}
 
-		if dft.HasDrift {
+		tableRow = append(tableRow, changedBy(dft))
+
+		switch {
+		case dft.Status == deviation.StatusTimedOut:
+			switch !drift.NoColor {
+			case true:
+				table.Rich(tableRow, []tablewriter.Colors{{}, {}, {tablewriter.FgYellowColor}})
+			default:
+				table.Append(tableRow)
+			}
+		case dft.HasDrift:
 			switch !drift.NoColor {
 			case true:
 				table.Rich(tableRow, []tablewriter.Colors{{}, {}, {tablewriter.FgRedColor}})
 			default:
 				table.Append(tableRow)
 			}
-		} else {
+		default:
 			switch !drift.NoColor {
 			case true:
 				table.Rich(tableRow, []tablewriter.Colors{{}, {}, {tablewriter.FgGreenColor}})
@@ -84,15 +120,30 @@ func (drift *Drift) runTable(table *tablewriter.Table, deviations []*deviation.D
 
 	dvn := deviation.Deviations(drifts.Deviations)
 	hasDrift := dvn.Status()
-	table.SetFooter([]string{"", "Status", hasDrift})
+
+	footer := []string{statusCounts(drifts.Deviations), "Status", hasDrift}
+	if drift.History {
+		footer = append(footer, "")
+	}
+
+	footer = append(footer, "")
+
+	table.SetFooter(footer)
 	table.SetCaption(true, drift.getCaption())
 
 	if !drift.NoColor {
+		footerColors := []tablewriter.Colors{{}, {tablewriter.Bold}, {tablewriter.FgGreenColor}}
 		if dvn.Status() == deviation.Failed {
-			table.SetFooterColor(tablewriter.Colors{}, tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.FgRedColor})
-		} else {
-			table.SetFooterColor(tablewriter.Colors{}, tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.FgGreenColor})
+			footerColors[2] = tablewriter.Colors{tablewriter.FgRedColor}
+		}
+
+		if drift.History {
+			footerColors = append(footerColors, tablewriter.Colors{})
 		}
+
+		footerColors = append(footerColors, tablewriter.Colors{})
+
+		table.SetFooterColor(footerColors...)
 	}
 
 	return hasDrift == deviation.Failed
@@ -100,6 +151,7 @@ func (drift *Drift) runTable(table *tablewriter.Table, deviations []*deviation.D
 
 func (drift *Drift) allTable(table *tablewriter.Table, deviations []*deviation.DriftedRelease) bool {
 	table.SetHeader([]string{"release", "namespace", "drifted"})
+	table.SetHeaderColor(boldColors(3)...) //nolint:mnd
 
 	for _, dvn := range deviations {
 		tableRow := []string{dvn.Release, dvn.Namespace, dvn.Drifted()}
@@ -124,7 +176,12 @@ func (drift *Drift) allTable(table *tablewriter.Table, deviations []*deviation.D
 	dvn := deviation.DriftedReleases(deviations)
 	dvnStatus := dvn.Status()
 
-	table.SetFooter([]string{"", "Status", dvnStatus})
+	releaseDeviations := make([]*deviation.Deviation, 0)
+	for _, release := range deviations {
+		releaseDeviations = append(releaseDeviations, release.Deviations...)
+	}
+
+	table.SetFooter([]string{statusCounts(releaseDeviations), "Status", dvnStatus})
 
 	if !drift.NoColor {
 		if dvnStatus == deviation.Failed {
@@ -137,18 +194,86 @@ func (drift *Drift) allTable(table *tablewriter.Table, deviations []*deviation.D
 	return dvnStatus == deviation.Failed
 }
 
+// allResourceTable renders a row for every resource of the releases that is not in sync, grouped by the release,
+// with the number of such resources of every release in the footer.
+// Only the cells of the release are merged, the namespace is repeated on every row since releases share namespaces.
+func (drift *Drift) allResourceTable(table *tablewriter.Table, deviations []*deviation.DriftedRelease) bool {
+	table.SetHeader([]string{"release", "namespace", "kind", "name", "drift", "changed by"})
+	table.SetHeaderColor(boldColors(6)...) //nolint:mnd
+	table.SetAutoMergeCellsByColumnIndex([]int{0})
+
+	releaseCounts := make([]string, 0)
+	releaseDeviations := make([]*deviation.Deviation, 0)
+
+	for _, release := range deviations {
+		var count int
+
+		for _, dvn := range release.Deviations {
+			if !dvn.HasDrift && len(dvn.Status) == 0 {
+				continue
+			}
+
+			count++
+
+			tableRow := []string{release.Release, release.Namespace, dvn.Kind, dvn.Resource, dvn.Drifted(), changedBy(dvn)}
+
+			switch {
+			case drift.NoColor:
+				table.Append(tableRow)
+			case dvn.Status == deviation.StatusTimedOut:
+				table.Rich(tableRow, []tablewriter.Colors{{}, {}, {}, {}, {tablewriter.FgYellowColor}})
+			case dvn.Status == deviation.StatusBaselined:
+				table.Rich(tableRow, []tablewriter.Colors{{}, {}, {}, {}, {tablewriter.FgGreenColor}})
+			default:
+				table.Rich(tableRow, []tablewriter.Colors{{}, {}, {}, {}, {tablewriter.FgRedColor}})
+			}
+		}
+
+		if count != 0 {
+			releaseCounts = append(releaseCounts, fmt.Sprintf("%s: %d", release.Release, count))
+		}
+
+		releaseDeviations = append(releaseDeviations, release.Deviations...)
+	}
+
+	releases := deviation.DriftedReleases(deviations)
+	dvnStatus := releases.Status()
+
+	table.SetFooter([]string{strings.Join(releaseCounts, ", "), "", statusCounts(releaseDeviations), "Status", dvnStatus, ""})
+
+	if !drift.NoColor {
+		statusColor := tablewriter.Colors{tablewriter.FgGreenColor}
+		if dvnStatus == deviation.Failed {
+			statusColor = tablewriter.Colors{tablewriter.FgRedColor}
+		}
+
+		table.SetFooterColor(tablewriter.Colors{}, tablewriter.Colors{}, tablewriter.Colors{}, tablewriter.Colors{tablewriter.Bold}, statusColor,
+			tablewriter.Colors{})
+	}
+
+	return dvnStatus == deviation.Failed
+}
+
 func (drift *Drift) print(drifts []*deviation.DriftedRelease) {
 	if len(drifts) == 0 {
-		drift.flush()
-		os.Exit(0)
+		return
 	}
 
 	drft := drifts[0]
 	deviations := deviation.Deviations(drft.Deviations)
 	release := deviation.DriftedReleases(drifts)
 
+	timedOut, orphaned, missing, extra, baselined := 0, 0, 0, 0, 0
+
 	for _, dft := range drifts {
-		if !dft.HasDrift {
+		releaseDeviations := deviation.Deviations(dft.Deviations)
+		timedOut += releaseDeviations.CountByStatus(deviation.StatusTimedOut)
+		orphaned += releaseDeviations.CountByStatus(deviation.StatusOrphaned)
+		missing += releaseDeviations.CountByStatus(deviation.StatusMissing)
+		extra += releaseDeviations.CountByStatus(deviation.StatusExtra)
+		baselined += releaseDeviations.CountByStatus(deviation.StatusBaselined)
+
+		if !dft.HasDrift && releaseDeviations.CountByStatus(deviation.StatusTimedOut) == 0 {
 			continue
 		}
 
@@ -159,30 +284,76 @@ func (drift *Drift) print(drifts []*deviation.DriftedRelease) {
 			drift.write(addNewLine(fmt.Sprintf("Chart                                  : %s", dft.Chart)))
 		}
 
+		if len(drift.against) != 0 {
+			drift.write(addNewLine(fmt.Sprintf("Compared against                       : %s", drift.against)))
+		}
+
 		for _, dvn := range dft.Deviations {
-			if dvn.HasDrift {
+			switch {
+			case dvn.Status == deviation.StatusOrphaned:
+				drift.write(addNewLine("------------------------------------------------------------------------------------"))
+				drift.write(addNewLine(fmt.Sprintf("Orphaned, not part of the release anymore: '%s' '%s' %s", dvn.Kind, dvn.Resource, inNameSpace(dvn.NameSpace))))
+			case dvn.Status == deviation.StatusMissing:
+				drift.write(addNewLine("------------------------------------------------------------------------------------"))
+				drift.write(addNewLine(fmt.Sprintf("Missing from %s: '%s' '%s'", drift.missingFrom(), dvn.Kind, dvn.Resource)))
+			case dvn.Status == deviation.StatusExtra:
+				drift.write(addNewLine("------------------------------------------------------------------------------------"))
+				drift.write(addNewLine(fmt.Sprintf("Only in %s: '%s' '%s'", drift.against, dvn.Kind, dvn.Resource)))
+			case dvn.Status == deviation.StatusTimedOut:
+				drift.write(addNewLine("------------------------------------------------------------------------------------"))
+				drift.write(addNewLine(fmt.Sprintf("Timed out identifying drifts in: '%s' '%s'", dvn.Kind, dvn.Resource)))
+			case dvn.Status == deviation.StatusBaselined:
+				drift.write(addNewLine("------------------------------------------------------------------------------------"))
+				drift.write(addNewLine(fmt.Sprintf("Drifts accepted by the baseline in: '%s' '%s'", dvn.Kind, dvn.Resource)))
+			case dvn.HasDrift:
 				drift.write(addNewLine("------------------------------------------------------------------------------------"))
 				drift.write(addNewLine(fmt.Sprintf("Identified drifts in: '%s' '%s'", dvn.Kind, dvn.Resource)))
 				drift.write(addNewLine("-----------"))
 				drift.write(addNewLine(""))
 				drift.write(dvn.Deviations)
 				drift.write(addNewLine(addNewLine("-----------")))
+				drift.printMatchedRevision(dvn)
 			}
+
+			drift.printSuppressed(dvn)
 		}
 
 		drift.write(addNewLine("------------------------------------------------------------------------------------"))
 	}
 
-	switch !release.Drifted() {
-	case true:
-		drift.write(addNewLine("YAY...! NO DRIFTS FOUND"))
-	default:
+	// with no drifts found in the resources that did not time out, whether the release drifted is not known.
+	switch {
+	case release.Drifted():
 		drift.write(addNewLine("OOPS...! DRIFTS FOUND"))
+	case timedOut != 0:
+		drift.write(addNewLine("HMM...! RESULT IS INDETERMINATE, RESOURCES TIMED OUT BEFORE THEIR DRIFTS COULD BE IDENTIFIED"))
+	default:
+		drift.write(addNewLine("YAY...! NO DRIFTS FOUND"))
 	}
 
 	drift.write(addNewLine("------------------------------------------------------------------------------------"))
 	drift.write(addNewLine(fmt.Sprintf("Total time spent on identifying drifts : %v", drift.timeSpent)))
 
+	if timedOut != 0 {
+		drift.write(addNewLine(fmt.Sprintf("Total number of resources timed out    : %v", timedOut)))
+	}
+
+	if missing != 0 {
+		drift.write(addNewLine(fmt.Sprintf("Total number of missing resources      : %v", missing)))
+	}
+
+	if extra != 0 {
+		drift.write(addNewLine(fmt.Sprintf("Total number of extra resources        : %v", extra)))
+	}
+
+	if orphaned != 0 {
+		drift.write(addNewLine(fmt.Sprintf("Total number of orphaned resources     : %v", orphaned)))
+	}
+
+	if baselined != 0 {
+		drift.write(addNewLine(fmt.Sprintf("Total number of drifts baselined       : %v", baselined)))
+	}
+
 	if drift.All {
 		drift.write(addNewLine(fmt.Sprintf("Total number of drifts found           : %v", deviations.Count())))
 		drift.write(addNewLine(fmt.Sprintf("Status                                 : %s", deviations.Status())))
@@ -194,17 +365,140 @@ func (drift *Drift) print(drifts []*deviation.DriftedRelease) {
 	drift.write(addNewLine("------------------------------------------------------------------------------------"))
 }
 
-func (drift *Drift) write(data string) {
-	_, err := drift.writer.Write([]byte(data))
-	if err != nil {
-		drift.log.Fatalln(err)
+// describe summarises the state of the resource (ex: 'missing from the cluster'), for the outputs reporting a line per resource.
+func (drift *Drift) describe(dvn *deviation.Deviation) string {
+	switch {
+	case dvn.Status == deviation.StatusOrphaned:
+		return "orphaned, not part of the release anymore"
+	case dvn.Status == deviation.StatusMissing:
+		return "missing from " + drift.missingFrom()
+	case dvn.Status == deviation.StatusExtra:
+		return "only in " + drift.against
+	case dvn.Status == deviation.StatusTimedOut:
+		return "timed out identifying drifts"
+	case dvn.Status == deviation.StatusBaselined:
+		return "drifts accepted by the baseline"
+	case dvn.HasDrift:
+		return "drifts identified"
+	default:
+		return "no drifts"
+	}
+}
+
+// missingFrom returns where the missing resources are missing from, the release compared against when comparing releases.
+func (drift *Drift) missingFrom() string {
+	if len(drift.against) != 0 {
+		return drift.against
 	}
+
+	return "the cluster"
+}
+
+func (drift *Drift) printMatchedRevision(dvn *deviation.Deviation) {
+	if !drift.History {
+		return
+	}
+
+	if dvn.MatchedRevision == 0 {
+		drift.write(addNewLine(addNewLine("Live state matches none of the other revisions of the release, it was likely edited in place")))
+
+		return
+	}
+
+	drift.write(addNewLine(addNewLine(fmt.Sprintf("Live state matches revision '%d' of the release", dvn.MatchedRevision))))
+}
+
+func (drift *Drift) printSuppressed(dvn *deviation.Deviation) {
+	if len(dvn.Suppressed) == 0 {
+		return
+	}
+
+	drift.write(addNewLine(fmt.Sprintf("Suppressed drifts in: '%s' '%s'", dvn.Kind, dvn.Resource)))
+
+	for _, change := range dvn.Suppressed {
+		drift.write(addNewLine(fmt.Sprintf("  %s (%s, suppressed by %s)", change.Path, change.Type, change.SuppressedBy)))
+	}
+
+	drift.write(addNewLine(""))
+}
+
+// write buffers the data to the writer, errors while writing are retained by the writer and are returned on flush.
+func (drift *Drift) write(data string) {
+	_, _ = drift.writer.Write([]byte(data))
 }
 
-func (drift *Drift) flush() {
+func (drift *Drift) flush() error {
 	if err := drift.writer.Flush(); err != nil {
-		drift.log.Fatalln(err)
+		return &errors.DriftError{Message: fmt.Sprintf("writing the drifts errored with '%v'", err)}
 	}
+
+	return nil
+}
+
+// statusCounts summarises the number of resources in each of the states other than drifted (ex: 'missing: 2'), to be set on the table footer.
+func statusCounts(deviations deviation.Deviations) string {
+	counts := make([]string, 0)
+
+	statuses := []string{deviation.StatusMissing, deviation.StatusExtra, deviation.StatusOrphaned, deviation.StatusTimedOut, deviation.StatusBaselined}
+
+	for _, status := range statuses {
+		if count := deviations.CountByStatus(status); count != 0 {
+			counts = append(counts, fmt.Sprintf("%s: %d", status, count))
+		}
+	}
+
+	return strings.Join(counts, ", ")
+}
+
+func boldColors(count int) []tablewriter.Colors {
+	colors := make([]tablewriter.Colors, count)
+	for index := range colors {
+		colors[index] = tablewriter.Colors{tablewriter.Bold}
+	}
+
+	return colors
+}
+
+// matchedRevision returns the revision matching the live state of the drifted resource to be set on the table, 'none' when it matches none.
+func matchedRevision(dvn *deviation.Deviation) string {
+	switch {
+	case !dvn.HasDrift || len(dvn.Status) != 0:
+		return ""
+	case dvn.MatchedRevision == 0:
+		return "none"
+	default:
+		return strconv.Itoa(dvn.MatchedRevision)
+	}
+}
+
+// changedBy lists the field managers that last updated the fields drifted of the resource, along with the time of their last update.
+func changedBy(dvn *deviation.Deviation) string {
+	managers := make([]string, 0)
+
+	for _, change := range dvn.Changes {
+		if len(change.Manager) == 0 {
+			continue
+		}
+
+		manager := change.Manager
+		if len(change.UpdatedAt) != 0 {
+			manager = fmt.Sprintf("%s (%s)", change.Manager, change.UpdatedAt)
+		}
+
+		if !slices.Contains(managers, manager) {
+			managers = append(managers, manager)
+		}
+	}
+
+	return strings.Join(managers, ", ")
+}
+
+func inNameSpace(nameSpace string) string {
+	if len(nameSpace) == 0 {
+		return "(cluster scoped)"
+	}
+
+	return fmt.Sprintf("in namespace '%s'", nameSpace)
 }
 
 func addNewLine(message string) string {
@@ -234,17 +528,39 @@ func (drift *Drift) getCaption() string {
 	return fmt.Sprintf("Namespace: '%s'\nRelease: '%s'", drift.namespace, drift.release)
 }
 
-func (drift *Drift) SetOutputFormats() {
+func (drift *Drift) SetOutputFormats() error {
+	drift.yaml, drift.json, drift.csv, drift.table = false, false, false, false
+	drift.junit, drift.sarif, drift.html, drift.markdown = false, false, false, false
+
 	switch strings.ToLower(drift.OutputFormat) {
 	case "yaml", "y":
 		drift.yaml = true
 	case "json", "j":
 		drift.json = true
+	case "csv", "c":
+		drift.csv = true
 	case "table", "t":
 		drift.table = true
+	case "junit":
+		drift.junit = true
+	case "sarif":
+		drift.sarif = true
+	case "html":
+		drift.html = true
+	case "markdown", "md":
+		drift.markdown = true
 	default:
 		if len(drift.OutputFormat) != 0 {
-			drift.log.Fatalf("helm drift does not support format '%s', switching to default", drift.OutputFormat)
+			return &errors.DriftError{Message: fmt.Sprintf("helm drift does not support format '%s', it should be one of yaml|json|csv|table|junit|sarif|html|markdown",
+				drift.OutputFormat)}
 		}
 	}
+
+	switch drift.TableDetail {
+	case TableDetailRelease, TableDetailResource, "":
+	default:
+		return &errors.DriftError{Message: fmt.Sprintf("helm drift does not support table detail '%s', it should be one of release|resource", drift.TableDetail)}
+	}
+
+	return nil
 }
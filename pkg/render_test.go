@@ -2,10 +2,13 @@ package pkg
 
 import (
 	"bytes"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRunTableAndAllTable(t *testing.T) {
@@ -33,6 +36,54 @@ func TestRunTableAndAllTable(t *testing.T) {
 	assert.True(t, hasDrift)
 }
 
+func TestRunTableChangedBy(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	drift := Drift{NoColor: true, OutputFormat: "table"}
+	drift.SetLogger("error")
+	drift.SetWriter(buffer)
+	require.NoError(t, drift.SetOutputFormats())
+
+	drift.toTABLE([]*deviation.DriftedRelease{{
+		Release: "release",
+		Deviations: []*deviation.Deviation{
+			{Kind: "Deployment", Resource: "sample", HasDrift: true, Changes: []*deviation.Change{
+				{Path: "spec.replicas", Manager: "kubectl-edit", UpdatedAt: "2026-10-01T11:00:00Z"},
+				{Path: "spec.minReadySeconds", Manager: "kubectl-edit", UpdatedAt: "2026-10-01T11:00:00Z"},
+				{Path: "spec.paused"},
+			}},
+		},
+	}})
+	require.NoError(t, drift.flush())
+
+	assert.Contains(t, buffer.String(), "CHANGED BY")
+	assert.Regexp(t, `Deployment\s+\|\s+sample\s+\|\s+YES\s+\|\s+kubectl-edit\s`, buffer.String())
+	assert.Equal(t, 1, strings.Count(buffer.String(), "(2026-10-01T11:00:00Z)"))
+}
+
+func TestAllResourceTableNamespaces(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	drift := Drift{NoColor: true, OutputFormat: "table", All: true, TableDetail: TableDetailResource}
+	drift.SetLogger("error")
+	drift.SetWriter(buffer)
+	require.NoError(t, drift.SetOutputFormats())
+
+	drift.toTABLE([]*deviation.DriftedRelease{
+		{
+			Release: "first", Namespace: "shared", HasDrift: true,
+			Deviations: []*deviation.Deviation{{Kind: "Deployment", Resource: "first", HasDrift: true}},
+		},
+		{
+			Release: "second", Namespace: "shared", HasDrift: true,
+			Deviations: []*deviation.Deviation{{Kind: "Deployment", Resource: "second", HasDrift: true}},
+		},
+	})
+	require.NoError(t, drift.flush())
+
+	assert.Regexp(t, `first\s+\|\s+shared\s+\|\s+Deployment\s+\|\s+first\s`, buffer.String())
+	assert.Regexp(t, `second\s+\|\s+shared\s+\|\s+Deployment\s+\|\s+second\s`, buffer.String(),
+		"the namespace shared by the releases should not be merged across them")
+}
+
 func TestWriteAndFlush(t *testing.T) {
 	buffer := new(bytes.Buffer)
 	drift := Drift{}
@@ -40,7 +91,178 @@ func TestWriteAndFlush(t *testing.T) {
 	drift.SetWriter(buffer)
 
 	drift.write("hello")
-	drift.flush()
+	require.NoError(t, drift.flush())
 
 	assert.Equal(t, "hello", buffer.String())
 }
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func TestFlushErrorsOnFailedWrite(t *testing.T) {
+	drift := Drift{}
+	drift.SetLogger("error")
+	drift.SetWriter(failingWriter{})
+
+	drift.write("hello")
+
+	err := drift.flush()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "writing the drifts errored")
+}
+
+func TestRenderDoesNotExitOnDrift(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	drift := Drift{NoColor: true}
+	drift.SetLogger("error")
+	drift.SetWriter(buffer)
+
+	err := drift.render([]*deviation.DriftedRelease{{
+		Release:    "release",
+		HasDrift:   true,
+		Deviations: []*deviation.Deviation{{Kind: "Deployment", Resource: "sample", HasDrift: true, Deviations: "diff"}},
+	}})
+	require.NoError(t, err)
+	assert.Contains(t, buffer.String(), "OOPS...! DRIFTS FOUND")
+
+	buffer.Reset()
+	require.NoError(t, drift.render(nil))
+}
+
+func TestPrintSuppressed(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	drift := Drift{}
+	drift.SetLogger("error")
+	drift.SetWriter(buffer)
+
+	drift.printSuppressed(&deviation.Deviation{Kind: "ConfigMap", Resource: "foo"})
+	drift.printSuppressed(&deviation.Deviation{
+		Kind:     "ConfigMap",
+		Resource: "foo",
+		Suppressed: []*deviation.Change{
+			{Path: `data.ca\.crt`, Type: deviation.ChangeModified, SuppressedBy: "ignore-rule"},
+		},
+	})
+	require.NoError(t, drift.flush())
+
+	assert.Equal(t, "Suppressed drifts in: 'ConfigMap' 'foo'\n  data.ca\\.crt (modified, suppressed by ignore-rule)\n\n", buffer.String())
+}
+
+func TestPrintTimedOut(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	drift := Drift{NoColor: true}
+	drift.SetLogger("error")
+	drift.SetWriter(buffer)
+
+	drift.print([]*deviation.DriftedRelease{{
+		Release:    "release",
+		Deviations: []*deviation.Deviation{{Kind: "Deployment", Resource: "sample", Status: deviation.StatusTimedOut}},
+	}})
+	require.NoError(t, drift.flush())
+
+	assert.Contains(t, buffer.String(), "Timed out identifying drifts in: 'Deployment' 'sample'")
+	assert.Contains(t, buffer.String(), "Total number of resources timed out    : 1")
+	assert.Contains(t, buffer.String(), "HMM...! RESULT IS INDETERMINATE, RESOURCES TIMED OUT BEFORE THEIR DRIFTS COULD BE IDENTIFIED")
+	assert.NotContains(t, buffer.String(), "NO DRIFTS FOUND")
+}
+
+func TestPrintOrphaned(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	drift := Drift{NoColor: true}
+	drift.SetLogger("error")
+	drift.SetWriter(buffer)
+
+	drift.print([]*deviation.DriftedRelease{{
+		Release:  "release",
+		HasDrift: true,
+		Deviations: []*deviation.Deviation{
+			{Kind: "ConfigMap", Resource: "old", NameSpace: "sample", HasDrift: true, Status: deviation.StatusOrphaned},
+			{Kind: "ClusterRole", Resource: "old", HasDrift: true, Status: deviation.StatusOrphaned},
+		},
+	}})
+	require.NoError(t, drift.flush())
+
+	assert.Contains(t, buffer.String(), "Orphaned, not part of the release anymore: 'ConfigMap' 'old' in namespace 'sample'")
+	assert.Contains(t, buffer.String(), "Orphaned, not part of the release anymore: 'ClusterRole' 'old' (cluster scoped)")
+	assert.NotContains(t, buffer.String(), "Identified drifts in")
+	assert.Contains(t, buffer.String(), "Total number of orphaned resources     : 2")
+	assert.Contains(t, buffer.String(), "OOPS...! DRIFTS FOUND")
+}
+
+func TestRenderMissing(t *testing.T) {
+	drifts := []*deviation.DriftedRelease{{
+		Release:  "release",
+		HasDrift: true,
+		Deviations: []*deviation.Deviation{
+			{Kind: "Secret", Resource: "credentials", HasDrift: true, Deviations: "+apiVersion: v1\n", Status: deviation.StatusMissing},
+			{Kind: "Deployment", Resource: "sample", HasDrift: true, Deviations: "-  replicas: 2\n"},
+		},
+	}}
+
+	t.Run("should print the missing resources and count them separately", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+		drift := Drift{NoColor: true}
+		drift.SetLogger("error")
+		drift.SetWriter(buffer)
+
+		drift.print(drifts)
+		require.NoError(t, drift.flush())
+
+		assert.Contains(t, buffer.String(), "Missing from the cluster: 'Secret' 'credentials'")
+		assert.NotContains(t, buffer.String(), "Identified drifts in: 'Secret' 'credentials'")
+		assert.Contains(t, buffer.String(), "Identified drifts in: 'Deployment' 'sample'")
+		assert.Contains(t, buffer.String(), "Total number of missing resources      : 1")
+		assert.Contains(t, buffer.String(), "Total number of drifts found           : 1")
+	})
+
+	t.Run("should count the missing resources on the table footer", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+		drift := Drift{NoColor: true, OutputFormat: "table"}
+		drift.SetLogger("error")
+		drift.SetWriter(buffer)
+		require.NoError(t, drift.SetOutputFormats())
+
+		drift.toTABLE(drifts)
+		require.NoError(t, drift.flush())
+
+		assert.Contains(t, buffer.String(), "MISSING")
+		assert.Contains(t, buffer.String(), "MISSING: 1")
+	})
+}
+
+func TestStatusCounts(t *testing.T) {
+	assert.Empty(t, statusCounts([]*deviation.Deviation{{Kind: "Deployment", HasDrift: true}}))
+	assert.Equal(t, "missing: 2, timed-out: 1", statusCounts([]*deviation.Deviation{
+		{Status: deviation.StatusMissing},
+		{Status: deviation.StatusTimedOut},
+		{Status: deviation.StatusMissing},
+	}))
+}
+
+func TestAllResourceTable(t *testing.T) {
+	t.Run("should list the resources not in sync grouped by the release", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+		drift := Drift{All: true, NoColor: true, OutputFormat: "table", TableDetail: TableDetailResource}
+		drift.SetLogger("error")
+		drift.SetWriter(buffer)
+		require.NoError(t, drift.SetOutputFormats())
+
+		drift.toTABLE(newReportedReleases())
+		require.NoError(t, drift.flush())
+
+		assert.Contains(t, buffer.String(), "KIND")
+		assert.Regexp(t, `sample\s+\|\s+sample\s+\|\s+Deployment\s+\|\s+sample\s+\|\s+YES`, buffer.String())
+		assert.Regexp(t, `\|\s+ConfigMap\s+\|\s+old\s+\|\s+ORPHANED`, buffer.String())
+		assert.Regexp(t, `other\s+\|\s+other\s+\|\s+Secret\s+\|\s+credentials\s+\|\s+TIMED-OUT`, buffer.String())
+		assert.NotContains(t, buffer.String(), "Service", "resources in sync should not be listed")
+		assert.Contains(t, buffer.String(), "SAMPLE: 2, OTHER: 1")
+	})
+
+	t.Run("should not accept unknown table details", func(t *testing.T) {
+		drift := Drift{TableDetail: "chart"}
+		assert.EqualError(t, drift.SetOutputFormats(), "helm drift does not support table detail 'chart', it should be one of release|resource")
+	})
+}
@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"fmt"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"github.com/thoas/go-funk"
+)
+
+// Report holds the drifts identified from the selected release/chart or from all the releases.
+type Report struct {
+	Releases  []*deviation.DriftedRelease `json:"releases,omitempty"   yaml:"releases,omitempty"`
+	TimeSpent float64                     `json:"time_spent,omitempty" yaml:"time_spent,omitempty"`
+}
+
+// HasDrift returns true if at least one of the releases from the report has drifted.
+func (report *Report) HasDrift() bool {
+	if report == nil {
+		return false
+	}
+
+	releases := deviation.DriftedReleases(report.Releases)
+
+	return releases.Drifted()
+}
+
+// HasMissing returns true if at least one of the resources from the report is missing from the cluster.
+func (report *Report) HasMissing() bool {
+	if report == nil {
+		return false
+	}
+
+	return funk.Contains(report.Releases, func(release *deviation.DriftedRelease) bool {
+		return funk.Contains(release.Deviations, func(dvn *deviation.Deviation) bool {
+			return dvn.HasDrift && dvn.Status == deviation.StatusMissing
+		})
+	})
+}
+
+// TimedOut returns the number of resources from the report, whose drifts could not be identified in time.
+func (report *Report) TimedOut() int {
+	if report == nil {
+		return 0
+	}
+
+	var timedOut int
+
+	for _, release := range report.Releases {
+		deviations := deviation.Deviations(release.Deviations)
+		timedOut += deviations.CountByStatus(deviation.StatusTimedOut)
+	}
+
+	return timedOut
+}
+
+// ValidateFailOn validates the policy set to fail on the drifts identified.
+func (drift *Drift) ValidateFailOn() error {
+	switch drift.FailOn {
+	case FailOnAny, FailOnMissing, FailOnNone, "":
+		return nil
+	default:
+		return &errors.DriftError{Message: fmt.Sprintf("helm drift does not support failing on '%s', it should be one of any|missing|none", drift.FailOn)}
+	}
+}
+
+// ExitError returns errors.DriftsFoundError when the report has the drifts to fail on as per FailOn, so that helm drift exits
+// with the code for the drifts identified. It is nil when there are no such drifts or when DisableExitWithError is set.
+// Resources timed out are errors in identifying the drifts and not drifts, so the report having them always errors.
+func (drift *Drift) ExitError(report *Report) error {
+	if err := drift.ValidateFailOn(); err != nil {
+		return err
+	}
+
+	if timedOut := report.TimedOut(); timedOut != 0 {
+		return &errors.DriftError{
+			Message: fmt.Sprintf("identifying drifts of '%d' resources timed out, the drifts identified are incomplete", timedOut),
+		}
+	}
+
+	if drift.DisableExitWithError {
+		return nil
+	}
+
+	switch drift.FailOn {
+	case FailOnNone:
+		return nil
+	case FailOnMissing:
+		if !report.HasMissing() {
+			return nil
+		}
+	default:
+		if !report.HasDrift() {
+			return nil
+		}
+	}
+
+	return &errors.DriftsFoundError{Missing: report.HasMissing()}
+}
@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportHasDrift(t *testing.T) {
+	var report *Report
+	assert.False(t, report.HasDrift())
+
+	report = &Report{Releases: []*deviation.DriftedRelease{{Release: "clean"}}}
+	assert.False(t, report.HasDrift())
+
+	report.Releases = append(report.Releases, &deviation.DriftedRelease{Release: "drifted", HasDrift: true})
+	assert.True(t, report.HasDrift())
+}
+
+func TestReportHasMissing(t *testing.T) {
+	var report *Report
+	assert.False(t, report.HasMissing())
+
+	report = &Report{Releases: []*deviation.DriftedRelease{{
+		Release: "drifted", HasDrift: true, Deviations: []*deviation.Deviation{{Kind: "Deployment", Resource: "sample", HasDrift: true}},
+	}}}
+	assert.False(t, report.HasMissing())
+
+	report.Releases[0].Deviations = append(report.Releases[0].Deviations,
+		&deviation.Deviation{Kind: "ConfigMap", Resource: "sample", HasDrift: true, Status: deviation.StatusMissing})
+	assert.True(t, report.HasMissing())
+}
+
+func TestDrift_ExitError(t *testing.T) {
+	drifted := &Report{Releases: []*deviation.DriftedRelease{{
+		Release: "drifted", HasDrift: true, Deviations: []*deviation.Deviation{{Kind: "Deployment", Resource: "sample", HasDrift: true}},
+	}}}
+	missing := &Report{Releases: []*deviation.DriftedRelease{{
+		Release: "missing", HasDrift: true, Deviations: []*deviation.Deviation{{Kind: "Deployment", Resource: "sample", HasDrift: true, Status: deviation.StatusMissing}},
+	}}}
+	inSync := &Report{Releases: []*deviation.DriftedRelease{{Release: "in-sync"}}}
+	timedOut := &Report{Releases: []*deviation.DriftedRelease{{
+		Release: "timed-out", Deviations: []*deviation.Deviation{{Kind: "Deployment", Resource: "sample", Status: deviation.StatusTimedOut}},
+	}}}
+
+	tests := []struct {
+		name     string
+		drift    *Drift
+		report   *Report
+		expected int
+	}{
+		{name: "should not fail when there are no drifts", drift: &Drift{}, report: inSync, expected: errors.ExitCodeNoDrift},
+		{name: "should fail on any drift by default", drift: &Drift{}, report: drifted, expected: errors.ExitCodeDrift},
+		{name: "should fail on missing resources when set to fail on any", drift: &Drift{FailOn: FailOnAny}, report: missing, expected: errors.ExitCodeMissing},
+		{name: "should not fail on drifts when set to fail on missing resources", drift: &Drift{FailOn: FailOnMissing}, report: drifted, expected: errors.ExitCodeNoDrift},
+		{name: "should fail on missing resources when set to fail on them", drift: &Drift{FailOn: FailOnMissing}, report: missing, expected: errors.ExitCodeMissing},
+		{name: "should not fail when set to fail on none", drift: &Drift{FailOn: FailOnNone}, report: missing, expected: errors.ExitCodeNoDrift},
+		{name: "should not fail when exiting with error is disabled", drift: &Drift{DisableExitWithError: true}, report: drifted, expected: errors.ExitCodeNoDrift},
+		{name: "should error on unsupported policies", drift: &Drift{FailOn: "severe"}, report: drifted, expected: errors.ExitCodeError},
+		{name: "should error when resources timed out", drift: &Drift{}, report: timedOut, expected: errors.ExitCodeError},
+		{
+			name: "should error when resources timed out even when set to fail on none", drift: &Drift{FailOn: FailOnNone},
+			report: timedOut, expected: errors.ExitCodeError,
+		},
+		{
+			name: "should error when resources timed out even when exiting with error is disabled", drift: &Drift{DisableExitWithError: true},
+			report: timedOut, expected: errors.ExitCodeError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, errors.ExitCode(test.drift.ExitError(test.report)))
+		})
+	}
+}
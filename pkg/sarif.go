@@ -0,0 +1,192 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"github.com/nikhilsbhat/helm-drift/version"
+)
+
+const (
+	sarifSchema         = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion        = "2.1.0"
+	sarifInformationURI = "https://github.com/nikhilsbhat/helm-drift"
+	sarifRuleDrift      = "drift"
+	sarifLevelError     = "error"
+	sarifLevelWarning   = "warning"
+	sarifLevelNote      = "note"
+)
+
+type sarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool      `json:"tool"`
+	Results []*sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string       `json:"name"`
+	InformationURI string       `json:"informationUri"`
+	Version        string       `json:"version,omitempty"`
+	Rules          []*sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string           `json:"ruleId"`
+	Level      string           `json:"level"`
+	Message    sarifMessage     `json:"message"`
+	Locations  []*sarifLocation `json:"locations"`
+	Properties map[string]any   `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation  `json:"physicalLocation,omitempty"`
+	LogicalLocations []*sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifRules are the rules the results are reported against, one for drifts and one for each of the states of the resources.
+var sarifRules = []*sarifRule{
+	{ID: sarifRuleDrift, ShortDescription: sarifMessage{Text: "Live state of the resource has drifted from its manifest"}},
+	{ID: deviation.StatusMissing, ShortDescription: sarifMessage{Text: "Resource from the manifests is missing"}},
+	{ID: deviation.StatusExtra, ShortDescription: sarifMessage{Text: "Resource is present only in the release compared against"}},
+	{ID: deviation.StatusOrphaned, ShortDescription: sarifMessage{Text: "Resource owned by the release is not part of its manifests anymore"}},
+	{ID: deviation.StatusTimedOut, ShortDescription: sarifMessage{Text: "Drifts of the resource could not be identified in time"}},
+	{ID: deviation.StatusBaselined, ShortDescription: sarifMessage{Text: "Drifts of the resource are accepted by the baseline"}},
+}
+
+// toSARIF renders the drifts as a SARIF log, with a result for every resource that has drifted or is in one of the other states.
+// Results point to the chart templates the resources are rendered from, and to the resource from the release as the logical location.
+func (drift *Drift) toSARIF(drifts []*deviation.DriftedRelease) error {
+	out, err := json.MarshalIndent(drift.sarifReport(drifts), "", "  ")
+	if err != nil {
+		return &errors.DriftError{Message: fmt.Sprintf("rendering drifts as sarif errored with '%v'", err)}
+	}
+
+	drift.write(addNewLine(string(out)))
+
+	return drift.flush()
+}
+
+func (drift *Drift) sarifReport(drifts []*deviation.DriftedRelease) *sarifLog {
+	results := make([]*sarifResult, 0)
+
+	for _, release := range drifts {
+		for _, dvn := range release.Deviations {
+			if !dvn.HasDrift && len(dvn.Status) == 0 {
+				continue
+			}
+
+			result := &sarifResult{
+				RuleID:  sarifRuleDrift,
+				Level:   sarifLevelError,
+				Message: sarifMessage{Text: fmt.Sprintf("'%s' '%s' of release '%s': %s", dvn.Kind, dvn.Resource, release.Release, drift.describe(dvn))},
+				Properties: map[string]any{
+					"release":   release.Release,
+					"namespace": release.Namespace,
+				},
+			}
+
+			if len(dvn.Status) != 0 {
+				result.RuleID = dvn.Status
+			}
+
+			switch dvn.Status {
+			case deviation.StatusOrphaned, deviation.StatusTimedOut:
+				result.Level = sarifLevelWarning
+			case deviation.StatusBaselined:
+				result.Level = sarifLevelNote
+			}
+
+			if len(dvn.Deviations) != 0 {
+				result.Properties["diff"] = dvn.Deviations
+			}
+
+			location := &sarifLocation{LogicalLocations: []*sarifLogicalLocation{{
+				Name:               dvn.Resource,
+				FullyQualifiedName: fmt.Sprintf("%s/%s/%s/%s", release.Namespace, release.Release, dvn.Kind, dvn.Resource),
+				Kind:               "resource",
+			}}}
+
+			if len(dvn.Source) != 0 {
+				location.PhysicalLocation = &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: drift.sourceURI(dvn.Source)}}
+			}
+
+			result.Locations = []*sarifLocation{location}
+			results = append(results, result)
+		}
+	}
+
+	return &sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []*sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "helm-drift", InformationURI: sarifInformationURI, Version: version.Version, Rules: sarifRules}},
+			Results: results,
+		}},
+	}
+}
+
+// sourceURI returns the path of the chart template relative to the working directory, so that the results are annotated on the template
+// in the repository. The source set by helm starts with the name of the chart rather than its directory, which could be named otherwise,
+// so the name is replaced with the directory of the chart. The directory is known only when the chart is a local one.
+func (drift *Drift) sourceURI(source string) string {
+	if info, err := os.Stat(drift.chart); err != nil || !info.IsDir() {
+		return filepath.ToSlash(source)
+	}
+
+	_, templateSource, found := strings.Cut(filepath.ToSlash(source), "/")
+	if !found {
+		return filepath.ToSlash(source)
+	}
+
+	templatePath := filepath.Join(filepath.Clean(drift.chart), filepath.FromSlash(templateSource))
+
+	if filepath.IsAbs(templatePath) {
+		workingDir, err := os.Getwd()
+		if err != nil {
+			return filepath.ToSlash(source)
+		}
+
+		if templatePath, err = filepath.Rel(workingDir, templatePath); err != nil {
+			return filepath.ToSlash(source)
+		}
+	}
+
+	return filepath.ToSlash(templatePath)
+}
@@ -0,0 +1,82 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikhilsbhat/helm-drift/pkg/deviation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderSARIF(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	drift := Drift{OutputFormat: "sarif"}
+	drift.SetLogger("error")
+	drift.SetWriter(buffer)
+
+	require.NoError(t, drift.Render(&Report{Releases: newReportedReleases()}))
+
+	report := new(sarifLog)
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), report))
+
+	assert.Equal(t, sarifVersion, report.Version)
+	require.Len(t, report.Runs, 1)
+	assert.Equal(t, "helm-drift", report.Runs[0].Tool.Driver.Name)
+
+	results := report.Runs[0].Results
+	require.Len(t, results, 3, "only the resources that drifted or are in one of the other states should be reported")
+
+	assert.Equal(t, sarifRuleDrift, results[0].RuleID)
+	assert.Equal(t, sarifLevelError, results[0].Level)
+	assert.Equal(t, "'Deployment' 'sample' of release 'sample': drifts identified", results[0].Message.Text)
+	assert.Equal(t, "sample/templates/deployment.yaml", results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI,
+		"the template the manifest is rendered from should be located, and not the manifest rendered on to disk")
+	assert.Equal(t, "sample/sample/Deployment/sample", results[0].Locations[0].LogicalLocations[0].FullyQualifiedName)
+	assert.Equal(t, "-  replicas: 2\n+  replicas: 1\n", results[0].Properties["diff"])
+
+	assert.Equal(t, deviation.StatusOrphaned, results[1].RuleID)
+	assert.Equal(t, sarifLevelWarning, results[1].Level)
+	assert.Nil(t, results[1].Locations[0].PhysicalLocation, "resources with no template should not be located")
+
+	assert.Equal(t, deviation.StatusTimedOut, results[2].RuleID)
+	assert.Equal(t, "other", results[2].Properties["release"])
+}
+
+func TestSourceURI(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.MkdirAll(filepath.Join("charts", "sample", "templates"), 0o755))
+
+	t.Run("should locate the template relative to the working directory for the local charts", func(t *testing.T) {
+		drift := Drift{chart: filepath.Join("charts", "sample")}
+
+		assert.Equal(t, "charts/sample/templates/deployment.yaml", drift.sourceURI("sample/templates/deployment.yaml"))
+	})
+
+	t.Run("should locate the template relative to the working directory when the local chart is set with absolute path", func(t *testing.T) {
+		workingDir, err := os.Getwd()
+		require.NoError(t, err)
+
+		drift := Drift{chart: filepath.Join(workingDir, "charts", "sample")}
+
+		assert.Equal(t, "charts/sample/templates/deployment.yaml", drift.sourceURI("sample/templates/deployment.yaml"))
+	})
+
+	t.Run("should locate the template in the directory of the local chart when it is named other than the chart", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Join("deploy", "helm", "templates"), 0o755))
+
+		drift := Drift{chart: filepath.Join("deploy", "helm")}
+
+		assert.Equal(t, "deploy/helm/templates/deployment.yaml", drift.sourceURI("sample/templates/deployment.yaml"))
+		assert.Equal(t, "deploy/helm/charts/redis/templates/service.yaml", drift.sourceURI("sample/charts/redis/templates/service.yaml"))
+	})
+
+	t.Run("should locate the template as set by helm for the charts from repositories", func(t *testing.T) {
+		drift := Drift{chart: "prometheus-community/prometheus"}
+
+		assert.Equal(t, "prometheus/templates/deployment.yaml", drift.sourceURI("prometheus/templates/deployment.yaml"))
+	})
+}
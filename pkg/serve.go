@@ -0,0 +1,152 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	driftError "github.com/nikhilsbhat/helm-drift/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+const (
+	// DefaultListenAddress is the address on which 'helm drift serve' exposes the metrics, when not specified.
+	DefaultListenAddress = ":9090"
+	// DefaultMetricsPath is the path on which 'helm drift serve' exposes the metrics, when not specified.
+	DefaultMetricsPath = "/metrics"
+	// DefaultScanInterval is the interval at which 'helm drift serve' identifies drifts from all the releases, when not specified.
+	DefaultScanInterval = 5 * time.Minute
+
+	serverReadHeaderTimeout = 10 * time.Second
+	serverShutdownTimeout   = 10 * time.Second
+)
+
+// Serve identifies drifts from all the releases on every interval and exposes the results of the latest scan
+// as prometheus metrics on the address and path passed. It runs until the context is cancelled.
+// Releases whose drifts could not be identified are logged and counted as scan errors, they do not stop the server.
+func (drift *Drift) Serve(ctx context.Context, address, metricsPath string, interval time.Duration) error {
+	if interval <= 0 {
+		return &driftError.DriftError{Message: fmt.Sprintf("scan interval should be greater than zero, but got '%s'", interval)}
+	}
+
+	drift.All = true
+
+	if err := drift.setExternalDiff(); err != nil {
+		return err
+	}
+
+	metrics := newDriftMetrics()
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, promhttp.HandlerFor(metrics.registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/healthz", func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	})
+
+	server := &http.Server{Addr: address, Handler: mux, ReadHeaderTimeout: serverReadHeaderTimeout}
+
+	// listening before the first scan so that an address that cannot be bound fails the server right away,
+	// rather than after a scan of all the releases.
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", address)
+	if err != nil {
+		return &driftError.DriftError{Message: fmt.Sprintf("listening on '%s' errored with '%v'", address, err)}
+	}
+
+	serverErr := make(chan error, 1)
+
+	go func() {
+		drift.log.Infof("exposing metrics on '%s%s'", listener.Addr(), metricsPath)
+
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			drift.log.Errorf("shutting down metrics server errored with '%v'", err)
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		drift.scan(ctx, metrics)
+
+		select {
+		case <-ctx.Done():
+			drift.log.Info("stopping drift identification as the server is shutting down")
+
+			return nil
+		case err := <-serverErr:
+			return &driftError.DriftError{Message: fmt.Sprintf("serving metrics on '%s' errored with '%v'", address, err)}
+		case <-ticker.C:
+		}
+	}
+}
+
+// scan identifies drifts from all the releases and records the results on to the metrics, errors are only logged.
+func (drift *Drift) scan(ctx context.Context, metrics *driftMetrics) {
+	startTime := time.Now()
+
+	drift.log.Info("identifying drifts from all the releases")
+
+	drift.resetClusterCaches()
+
+	if err := drift.cleanManifests(true); err != nil {
+		drift.log.Errorf("cleaning old rendered files failed with: %v", err)
+		metrics.recordFailure()
+
+		return
+	}
+
+	defer func() {
+		if err := drift.cleanManifests(false); err != nil {
+			drift.log.Errorf("cleaning rendered files failed with: %v", err)
+		}
+	}()
+
+	ctx, cancel := withTimeout(ctx, drift.Timeout)
+	defer cancel()
+
+	driftedReleases, scanned, driftErrors, err := drift.identifyAllDrifts(ctx)
+	if err != nil {
+		drift.log.Errorf("identifying drifts errored with: %v", err)
+		metrics.recordFailure()
+
+		return
+	}
+
+	for _, driftErr := range driftErrors {
+		drift.log.Errorf("identifying drifts errored with: %s", driftErr)
+	}
+
+	metrics.record(driftedReleases, scanned, len(driftErrors), time.Since(startTime))
+
+	drift.log.Infof("identified drifts from %d releases in %s", scanned, time.Since(startTime).Round(time.Millisecond))
+}
+
+// resetClusterCaches discards what was cached from the cluster in the previous scan,
+// so that HPAs and the other mutators, API resources and objects owned by releases created since then are considered.
+func (drift *Drift) resetClusterCaches() {
+	drift.targetsCacheMu.Lock()
+	drift.targetsCache = nil
+	drift.targetsCacheMu.Unlock()
+
+	drift.ownedObjectsMu.Lock()
+	drift.ownedObjects = nil
+	drift.listableResources = nil
+	drift.ownedObjectsMu.Unlock()
+
+	if resettable, ok := drift.restMapper.(meta.ResettableRESTMapper); ok {
+		resettable.Reset()
+	}
+}
@@ -25,6 +25,12 @@ func (drift *Drift) ValidatePrerequisite() bool {
 		success = false
 	}
 
+	if drift.DiffEngine == DiffEngineNative {
+		drift.log.Debug("native diff engine is selected, hence 'kubectl' is not required to identify drifts")
+
+		return success
+	}
+
 	if goPath := exec.CommandContext(context.Background(), "kubectl"); goPath.Err != nil {
 		if !errors.Is(goPath.Err, exec.ErrDot) {
 			drift.log.Infof("%v", goPath.Err.Error())
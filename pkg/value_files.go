@@ -44,3 +44,19 @@ func (v *ValueFiles) Set(value string) error {
 
 	return nil
 }
+
+func (v *ValueFiles) Append(value string) error {
+	*v = append(*v, value)
+
+	return nil
+}
+
+func (v *ValueFiles) Replace(values []string) error {
+	*v = values
+
+	return nil
+}
+
+func (v *ValueFiles) GetSlice() []string {
+	return *v
+}